@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/crhuber/github-exporter/exporter"
+	"github.com/google/go-github/v64/github"
+	"github.com/urfave/cli/v2"
+)
+
+// check is the outcome of one check of the doctor command. A failed
+// critical check makes the command fail; the others only warn.
+type check struct {
+	name     string
+	ok       bool
+	critical bool
+	detail   string
+}
+
+// doctor checks that an export with the given flags can run, printing a
+// pass or fail line per check: the token, the API host, authentication, the
+// token scopes, the rate limit and the output directories. Checks that need
+// the API are skipped once the API cannot be used.
+func doctor(c *cli.Context) error {
+	checks := doctorChecks(c)
+
+	failed := 0
+	for _, check := range checks {
+		status := "PASS"
+		switch {
+		case !check.ok && check.critical:
+			status = "FAIL"
+			failed++
+		case !check.ok:
+			status = "WARN"
+		}
+		fmt.Printf("%-4s  %-14s  %s\n", status, check.name, check.detail)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d critical checks failed", failed)
+	}
+	return nil
+}
+
+func doctorChecks(c *cli.Context) []check {
+	checks := outputChecks(c)
+
+	host := c.String("hostname")
+	var tokens []string
+	if c.IsSet("app-id") {
+		if !c.IsSet("installation-id") || c.String("private-key") == "" {
+			return append(checks, check{"credentials", false, true, "--app-id requires --installation-id and --private-key"})
+		}
+		checks = append(checks, check{"credentials", true, true, fmt.Sprintf("Github App %d, installation %d", c.Int64("app-id"), c.Int64("installation-id"))})
+	} else {
+		var err error
+		if tokens, host, err = resolveTokens(c, host); err != nil {
+			return append(checks, check{"credentials", false, true, err.Error()})
+		}
+		checks = append(checks, check{"credentials", true, true, fmt.Sprintf("%d token(s)", len(tokens))})
+	}
+	if host == "" {
+		host = defaultHost
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client, _, _, err := newClient(c, host, tokens, &exporter.RateTracker{})
+	if err != nil {
+		return append(checks, check{"client", false, true, err.Error()})
+	}
+
+	// The meta endpoint needs no authentication, which tells an unreachable
+	// host apart from a rejected token.
+	if _, _, err := client.Meta.Get(ctx); err != nil {
+		return append(checks, check{"api", false, true, fmt.Sprintf("%s is not reachable: %v", client.BaseURL, err)})
+	}
+	checks = append(checks, check{"api", true, true, fmt.Sprintf("%s is reachable", client.BaseURL)})
+
+	if c.IsSet("app-id") {
+		repos, _, err := client.Apps.ListRepos(ctx, &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return append(checks, check{"authentication", false, true, err.Error()})
+		}
+		checks = append(checks, check{"authentication", true, true, fmt.Sprintf("installation can access %d repositories", repos.GetTotalCount())})
+	} else {
+		user, resp, err := client.Users.Get(ctx, "")
+		if err != nil {
+			return append(checks, check{"authentication", false, true, err.Error()})
+		}
+		checks = append(checks, check{"authentication", true, true, "authenticated as " + user.GetLogin()})
+		checks = append(checks, scopeCheck(c, resp))
+	}
+
+	limits, _, err := client.RateLimit.Get(ctx)
+	if err != nil {
+		return append(checks, check{"rate limit", false, false, err.Error()})
+	}
+	core := limits.GetCore()
+	checks = append(checks, check{"rate limit", core.Remaining >= core.Limit/10, false,
+		fmt.Sprintf("%d of %d core requests remaining, reset at %s", core.Remaining, core.Limit, core.Reset.Format("15:04"))})
+	return checks
+}
+
+// scopeCheck checks the scopes of a classic token, which Github reports in
+// the X-OAuth-Scopes header of resp. Fine-grained tokens report none, their
+// permissions are only known once a request is denied.
+func scopeCheck(c *cli.Context, resp *github.Response) check {
+	header, ok := resp.Header["X-Oauth-Scopes"]
+	if !ok {
+		return check{"scopes", true, false, "not reported, a fine-grained token is limited to the repositories it was granted"}
+	}
+	var scopes []string
+	for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	detail := strings.Join(scopes, ", ")
+	if detail == "" {
+		detail = "none"
+	}
+
+	var missing []string
+	if !slices.Contains(scopes, "repo") {
+		missing = append(missing, "repo (private repositories are left out)")
+	}
+	if (c.IsSet("team") || c.IsSet("members")) && !slices.Contains(scopes, "read:org") && !slices.Contains(scopes, "admin:org") {
+		missing = append(missing, "read:org (team members cannot be listed)")
+	}
+	if len(missing) > 0 {
+		return check{"scopes", false, false, detail + "; missing " + strings.Join(missing, ", ")}
+	}
+	return check{"scopes", true, false, detail}
+}
+
+// outputChecks checks that the directories the outputs are written to can
+// be written, without creating them.
+func outputChecks(c *cli.Context) []check {
+	var dirs []string
+	if dir := c.String("output-dir"); dir != "" {
+		dirs = append(dirs, dir)
+	} else {
+		outputs, err := parseOutputs(c.StringSlice("output"), parseFormats(c.String("format")))
+		if err != nil {
+			return []check{{"output", false, true, err.Error()}}
+		}
+		for _, output := range outputs {
+			// Placeholders are only known once the export runs.
+			if output.path == "-" || strings.Contains(output.path, "{") {
+				continue
+			}
+			if dir := filepath.Dir(output.path); !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	var checks []check
+	for _, dir := range dirs {
+		if err := writable(dir); err != nil {
+			checks = append(checks, check{"output", false, true, fmt.Sprintf("cannot write to %s: %v", dir, err)})
+		} else {
+			checks = append(checks, check{"output", true, true, "can write to " + dir})
+		}
+	}
+	return checks
+}
+
+// writable reports whether a file can be created in dir, or in the closest
+// existing parent of dir when it does not exist yet, by creating and
+// removing one.
+func writable(dir string) error {
+	for {
+		_, err := os.Stat(dir)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) || filepath.Dir(dir) == dir {
+			return err
+		}
+		dir = filepath.Dir(dir)
+	}
+	file, err := os.CreateTemp(dir, ".github-exporter-doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
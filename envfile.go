@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/urfave/cli/v2"
+)
+
+const defaultEnvFile = ".env"
+
+// loadEnvFile loads the variables of --env-file into the environment, without
+// overriding those already set, and fills the flags backed by environment
+// variables from them. The default .env is optional, an explicit
+// --env-file is not.
+func loadEnvFile(c *cli.Context) error {
+	path := c.String("env-file")
+	if err := godotenv.Load(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !c.IsSet("env-file") {
+			return nil
+		}
+		return err
+	}
+
+	// The flags were parsed before the file was loaded, so the variables
+	// it added are applied by hand. Flags given on the command line, or
+	// already set from the environment, keep their value.
+	for _, flag := range c.App.Flags {
+		envFlag, ok := flag.(cli.DocGenerationFlag)
+		if !ok {
+			continue
+		}
+		name := flag.Names()[0]
+		if c.IsSet(name) {
+			continue
+		}
+		for _, env := range envFlag.GetEnvVars() {
+			if value, ok := os.LookupEnv(env); ok {
+				if err := c.Set(name, value); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
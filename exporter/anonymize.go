@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Anonymizer replaces the logins, names, emails, team slugs and repository
+// owners in an export with pseudonyms. The pseudonym is an HMAC of the
+// identity keyed with a salt, so the same identity gets the same pseudonym
+// within an export and across exports sharing the salt, while pseudonyms
+// cannot be reversed by hashing a list of known logins without it.
+type Anonymizer struct {
+	salt    []byte
+	mu      sync.Mutex
+	mapping map[string]string
+}
+
+// NewAnonymizer returns an Anonymizer with an empty mapping, keyed with salt,
+// or with a random salt of its own when salt is empty.
+func NewAnonymizer(salt string) (*Anonymizer, error) {
+	key := []byte(salt)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &Anonymizer{salt: key, mapping: map[string]string{}}, nil
+}
+
+// Pseudonym returns the pseudonym of identity. Identities differing only in
+// case share a pseudonym; the empty identity stays empty.
+func (a *Anonymizer) Pseudonym(identity string) string {
+	if identity == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write([]byte(strings.ToLower(identity)))
+	pseudonym := "user-" + hex.EncodeToString(mac.Sum(nil))[:12]
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mapping[pseudonym] = identity
+	return pseudonym
+}
+
+// Mapping returns the identity behind every pseudonym handed out so far.
+func (a *Anonymizer) Mapping() map[string]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	mapping := make(map[string]string, len(a.mapping))
+	for pseudonym, identity := range a.mapping {
+		mapping[pseudonym] = identity
+	}
+	return mapping
+}
+
+// repo replaces the owner of the repository fullName, an "owner/name" pair.
+func (a *Anonymizer) repo(fullName string) string {
+	owner, name, ok := strings.Cut(fullName, "/")
+	if !ok {
+		return fullName
+	}
+	return a.Pseudonym(owner) + "/" + name
+}
+
+// trailers replaces the identities in the Co-authored-by trailers of a
+// commit message, the same way as those in Commit.CoAuthors.
+func (a *Anonymizer) trailers(message string) string {
+	lines := strings.Split(message, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) < len(coAuthorTrailer) || !strings.EqualFold(trimmed[:len(coAuthorTrailer)], coAuthorTrailer) {
+			continue
+		}
+		if coAuthor := strings.TrimSpace(trimmed[len(coAuthorTrailer):]); coAuthor != "" {
+			lines[i] = trimmed[:len(coAuthorTrailer)] + " " + a.Pseudonym(coAuthor)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// anonymize replaces the identities in the records of export.
+func (a *Anonymizer) anonymize(export *Export) {
+	if a == nil {
+		return
+	}
+	for i := range export.Commits {
+		commit := &export.Commits[i]
+		commit.Repo = a.repo(commit.Repo)
+		commit.Message = a.trailers(commit.Message)
+		commit.Body = a.trailers(commit.Body)
+		commit.Author = a.Pseudonym(commit.Author)
+		commit.AuthorEmail = a.Pseudonym(commit.AuthorEmail)
+		commit.AuthorLogin = a.Pseudonym(commit.AuthorLogin)
+		commit.CommitterName = a.Pseudonym(commit.CommitterName)
+		commit.CommitterLogin = a.Pseudonym(commit.CommitterLogin)
+		commit.Member = a.Pseudonym(commit.Member)
+		for j, coAuthor := range commit.CoAuthors {
+			commit.CoAuthors[j] = a.Pseudonym(coAuthor)
+		}
+	}
+	for i := range export.PullRequests {
+		export.PullRequests[i].Repo = a.repo(export.PullRequests[i].Repo)
+		export.PullRequests[i].Author = a.Pseudonym(export.PullRequests[i].Author)
+		export.PullRequests[i].Member = a.Pseudonym(export.PullRequests[i].Member)
+		for j, reviewer := range export.PullRequests[i].RequestedReviewers {
+			export.PullRequests[i].RequestedReviewers[j] = a.Pseudonym(reviewer)
+		}
+		for j, team := range export.PullRequests[i].RequestedTeams {
+			export.PullRequests[i].RequestedTeams[j] = a.Pseudonym(team)
+		}
+		for j := range export.PullRequests[i].Commits {
+			commit := &export.PullRequests[i].Commits[j]
+			commit.Message = a.trailers(commit.Message)
+		}
+	}
+	for i := range export.Issues {
+		export.Issues[i].Repo = a.repo(export.Issues[i].Repo)
+		export.Issues[i].Author = a.Pseudonym(export.Issues[i].Author)
+		export.Issues[i].Member = a.Pseudonym(export.Issues[i].Member)
+	}
+	for i := range export.Releases {
+		export.Releases[i].Repo = a.repo(export.Releases[i].Repo)
+		// Download URLs name the owner of the repository.
+		for j := range export.Releases[i].Assets {
+			export.Releases[i].Assets[j].DownloadURL = ""
+		}
+		export.Releases[i].Author = a.Pseudonym(export.Releases[i].Author)
+		export.Releases[i].Member = a.Pseudonym(export.Releases[i].Member)
+	}
+	for i := range export.Watch {
+		export.Watch[i].Repo = a.repo(export.Watch[i].Repo)
+		export.Watch[i].Author = a.Pseudonym(export.Watch[i].Author)
+		export.Watch[i].Member = a.Pseudonym(export.Watch[i].Member)
+	}
+	for i := range export.CheckRuns {
+		export.CheckRuns[i].Repo = a.repo(export.CheckRuns[i].Repo)
+		export.CheckRuns[i].Member = a.Pseudonym(export.CheckRuns[i].Member)
+	}
+	for i := range export.Deployments {
+		export.Deployments[i].Repo = a.repo(export.Deployments[i].Repo)
+		export.Deployments[i].Creator = a.Pseudonym(export.Deployments[i].Creator)
+		export.Deployments[i].Member = a.Pseudonym(export.Deployments[i].Member)
+	}
+	for i := range export.Timeline {
+		event := &export.Timeline[i]
+		event.Repo = a.repo(event.Repo)
+		event.Actor = a.Pseudonym(event.Actor)
+		event.Assignee = a.Pseudonym(event.Assignee)
+		event.Member = a.Pseudonym(event.Member)
+	}
+	for i := range export.Discussions {
+		export.Discussions[i].Repo = a.repo(export.Discussions[i].Repo)
+		export.Discussions[i].Author = a.Pseudonym(export.Discussions[i].Author)
+		export.Discussions[i].Member = a.Pseudonym(export.Discussions[i].Member)
+	}
+	for i := range export.Collaborators {
+		export.Collaborators[i].Repo = a.repo(export.Collaborators[i].Repo)
+		export.Collaborators[i].Login = a.Pseudonym(export.Collaborators[i].Login)
+		export.Collaborators[i].Member = a.Pseudonym(export.Collaborators[i].Member)
+	}
+	for i := range export.Stargazers {
+		export.Stargazers[i].Repo = a.repo(export.Stargazers[i].Repo)
+		export.Stargazers[i].Login = a.Pseudonym(export.Stargazers[i].Login)
+		export.Stargazers[i].Member = a.Pseudonym(export.Stargazers[i].Member)
+	}
+}
+
+// anonymizeMeta replaces the identities in the metadata of export: its meta
+// object, repository snapshot, latest releases and listing stats.
+func (a *Anonymizer) anonymizeMeta(export *Export) {
+	if a == nil {
+		return
+	}
+	for i := range export.Repositories {
+		export.Repositories[i].FullName = a.repo(export.Repositories[i].FullName)
+	}
+	if export.LatestReleases != nil {
+		releases := map[string]string{}
+		for repo, tag := range export.LatestReleases {
+			releases[a.repo(repo)] = tag
+		}
+		export.LatestReleases = releases
+	}
+	if export.Stats != nil {
+		for i := range export.Stats.Listings {
+			export.Stats.Listings[i].Repo = a.repo(export.Stats.Listings[i].Repo)
+		}
+	}
+	meta := &export.Meta
+	meta.Login = a.Pseudonym(meta.Login)
+	// Members is shared with Options.Members, leave the caller's slice be.
+	meta.Members = slices.Clone(meta.Members)
+	for i, member := range meta.Members {
+		meta.Members[i] = a.Pseudonym(member)
+	}
+}
@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// releaseAssets returns the assets of release. Github includes every asset
+// in the release itself, so listing them costs no extra request.
+func releaseAssets(repo string, release *github.RepositoryRelease) []ReleaseAsset {
+	var assets []ReleaseAsset
+	for _, asset := range release.Assets {
+		assets = append(assets, ReleaseAsset{
+			ID:            asset.GetID(),
+			Name:          asset.GetName(),
+			Size:          asset.GetSize(),
+			DownloadURL:   asset.GetBrowserDownloadURL(),
+			DownloadCount: asset.GetDownloadCount(),
+			repo:          repo,
+		})
+	}
+	return assets
+}
+
+// DownloadAssets downloads the release assets of export into dir, one
+// <repo>/<tag>/<name> file per asset, and records the path of that file in
+// each asset. Assets read back from an earlier export, which lack the
+// repository they belong to, are skipped. Github redirects the download to
+// storage that rejects the API credentials, which is followed with storage,
+// a client without them; http.DefaultClient is used when nil.
+func DownloadAssets(ctx context.Context, client *github.Client, storage *http.Client, export *Export, dir string) error {
+	if storage == nil {
+		storage = http.DefaultClient
+	}
+	for i := range export.Releases {
+		release := &export.Releases[i]
+		for j := range release.Assets {
+			asset := &release.Assets[j]
+			if asset.repo == "" {
+				continue
+			}
+			path := filepath.Join(dir, filepath.FromSlash(release.Repo), release.TagName, filepath.Base(asset.Name))
+			if err := downloadAsset(ctx, client, storage, asset, path); err != nil {
+				return fmt.Errorf("downloading %s of %s %s: %w", asset.Name, release.Repo, release.TagName, err)
+			}
+			asset.Path = path
+		}
+	}
+	return nil
+}
+
+// downloadAsset writes asset to path. The asset is requested through the
+// API so private repositories work too, and the redirect to storage is
+// followed with the storage client.
+func downloadAsset(ctx context.Context, client *github.Client, storage *http.Client, asset *ReleaseAsset, path string) error {
+	owner, name, err := parseRepo(asset.repo)
+	if err != nil {
+		return err
+	}
+	body, _, err := client.Repositories.DownloadReleaseAsset(ctx, owner, name, asset.ID, storage)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,49 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadAssetsFollowsRedirectWithStorageClient(t *testing.T) {
+	// The storage serves a certificate only its own client trusts, like a
+	// Github Enterprise host with a private CA.
+	storage := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "binary")
+	}))
+	t.Cleanup(storage.Close)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octo/repo/releases/assets/7" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, storage.URL+"/asset", http.StatusFound)
+	}))
+
+	dir := t.TempDir()
+	export := Export{Releases: []Release{{
+		Repo:    "octo/repo",
+		TagName: "v1.0.0",
+		Assets:  []ReleaseAsset{{ID: 7, Name: "tool.tar.gz", repo: "octo/repo"}},
+	}}}
+	if err := DownloadAssets(context.Background(), client, storage.Client(), &export, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "octo", "repo", "v1.0.0", "tool.tar.gz")
+	if got := export.Releases[0].Assets[0].Path; got != path {
+		t.Errorf("asset path %q, want %q", got, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "binary" {
+		t.Errorf("downloaded %q, want the storage response", data)
+	}
+}
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"slices"
+	"strings"
+)
+
+// isBot reports whether login belongs to a bot: an App account, whose login
+// ends in [bot], or one of the extra bot logins in opts.Bots.
+func (opts Options) isBot(login string) bool {
+	if strings.HasSuffix(login, "[bot]") {
+		return true
+	}
+	return slices.ContainsFunc(opts.Bots, func(bot string) bool { return strings.EqualFold(bot, login) })
+}
+
+// dropBots removes the records authored by bots from export when
+// opts.ExcludeBots is set.
+func (opts Options) dropBots(export *Export) {
+	if !opts.ExcludeBots {
+		return
+	}
+	export.Commits = dropAuthors(export.Commits, opts.isBot)
+	export.PullRequests = dropAuthors(export.PullRequests, opts.isBot)
+	export.Issues = dropAuthors(export.Issues, opts.isBot)
+	export.Releases = dropAuthors(export.Releases, opts.isBot)
+	export.Watch = dropAuthors(export.Watch, opts.isBot)
+	export.CheckRuns = dropAuthors(export.CheckRuns, opts.isBot)
+	export.Deployments = dropAuthors(export.Deployments, opts.isBot)
+	export.Timeline = dropAuthors(export.Timeline, opts.isBot)
+	export.Discussions = dropAuthors(export.Discussions, opts.isBot)
+	export.Collaborators = dropAuthors(export.Collaborators, opts.isBot)
+	export.Stargazers = dropAuthors(export.Stargazers, opts.isBot)
+}
+
+func dropAuthors[T record](records []T, drop func(login string) bool) []T {
+	return slices.DeleteFunc(records, func(r T) bool { return drop(r.author()) })
+}
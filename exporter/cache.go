@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+type repoCache struct {
+	FetchedAt time.Time            `json:"fetched_at"`
+	Repos     []*github.Repository `json:"repos"`
+}
+
+// loadRepoCache returns the repositories cached in path if the cache exists
+// and is younger than ttl. The full repository objects are stored, so the
+// repository filters work the same on cached data.
+func loadRepoCache(path string, ttl time.Duration) ([]*github.Repository, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cache repoCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, false
+	}
+	if time.Since(cache.FetchedAt) > ttl {
+		return nil, false
+	}
+	return cache.Repos, true
+}
+
+func saveRepoCache(path string, repos []*github.Repository) error {
+	data, err := json.Marshal(repoCache{FetchedAt: time.Now(), Repos: repos})
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(path, data)
+}
+
+// writePrivateFile writes data to path readable by the current user only,
+// as the caches hold the data of private repositories. A file left readable
+// by an earlier version is restricted too.
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
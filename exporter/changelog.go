@@ -0,0 +1,168 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// changelogSections are the sections of a changelog, in order, keyed by the
+// Conventional Commits type of the entries they hold and listing the pull
+// request labels that put an entry in them. Entries of any other type end up
+// in the last section.
+var changelogSections = []changelogSection{
+	{"feat", "Features", []string{"feat", "feature", "enhancement"}},
+	{"fix", "Fixes", []string{"fix", "bug", "bugfix"}},
+	{"chore", "Chores", []string{"chore", "dependencies", "maintenance"}},
+	{"", "Other changes", nil},
+}
+
+type changelogSection struct {
+	kind, title string
+	labels      []string
+}
+
+// ChangelogKind reports whether the changelog format can render kind.
+func ChangelogKind(kind string) bool {
+	return kind == "commits" || kind == "pull_requests"
+}
+
+type changelogEntry struct {
+	repo, title, ref string
+	labels           []string
+}
+
+// writeChangelog writes the commits or merged pull requests of export as
+// Markdown release notes: a heading per repository, naming the release they
+// follow when exported with Options.SinceLastRelease, with the entries sorted
+// into sections by their pull request labels or else the Conventional Commits
+// prefix of their title ("feat: ...", "fix(api): ...").
+func writeChangelog(export Export, w io.Writer, opts WriteOptions) error {
+	var entries []changelogEntry
+	switch opts.Kind {
+	case "commits":
+		for _, commit := range export.Commits {
+			subject, _, _ := strings.Cut(commit.Message, "\n")
+			entries = append(entries, changelogEntry{commit.Repo, strings.TrimSpace(subject), shortSHA(commit.SHA), nil})
+		}
+	case "pull_requests":
+		for _, pr := range export.PullRequests {
+			// Unmerged pull requests are not part of any release.
+			if pr.MergedAt.IsZero() {
+				continue
+			}
+			entries = append(entries, changelogEntry{pr.Repo, pr.Title, fmt.Sprintf("#%d", pr.Number), pr.Labels})
+		}
+	default:
+		return fmt.Errorf("the changelog format does not support the %s kind", opts.Kind)
+	}
+
+	var repos []string
+	for _, entry := range entries {
+		if !slices.Contains(repos, entry.repo) {
+			repos = append(repos, entry.repo)
+		}
+	}
+	slices.Sort(repos)
+
+	for i, repo := range repos {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s\n", changelogHeading(export, repo))
+		sections := map[string][]string{}
+		for _, entry := range entries {
+			if entry.repo != repo {
+				continue
+			}
+			kind, line := changelogLine(entry)
+			sections[kind] = append(sections[kind], line)
+		}
+		for _, section := range changelogSections {
+			if len(sections[section.kind]) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "\n### %s\n\n", section.title)
+			if _, err := io.WriteString(w, strings.Join(sections[section.kind], "\n")+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// changelogHeading returns the heading of the entries of repo, which are
+// unreleased changes when the export followed the latest releases.
+func changelogHeading(export Export, repo string) string {
+	tag, ok := export.LatestReleases[repo]
+	switch {
+	case !ok:
+		return repo
+	case tag == "":
+		return repo + ": Unreleased"
+	default:
+		return fmt.Sprintf("%s: Unreleased since %s", repo, tag)
+	}
+}
+
+// changelogLine returns the section and Markdown list item of entry.
+func changelogLine(entry changelogEntry) (string, string) {
+	kind, scope, description, ok := conventionalTitle(entry.title)
+	if label := labelSection(entry.labels); label != "" {
+		if ok {
+			return label, changelogItem(scope, description, entry.ref)
+		}
+		return label, fmt.Sprintf("- %s (%s)", entry.title, entry.ref)
+	}
+	if !ok || !slices.ContainsFunc(changelogSections, func(s changelogSection) bool { return s.kind == kind }) {
+		return "", fmt.Sprintf("- %s (%s)", entry.title, entry.ref)
+	}
+	return kind, changelogItem(scope, description, entry.ref)
+}
+
+// labelSection returns the section the first of labels that names one puts
+// an entry in, or "" if none does.
+func labelSection(labels []string) string {
+	for _, label := range labels {
+		for _, section := range changelogSections {
+			if slices.ContainsFunc(section.labels, func(l string) bool { return strings.EqualFold(l, label) }) {
+				return section.kind
+			}
+		}
+	}
+	return ""
+}
+
+// changelogItem returns the Markdown list item of a change.
+func changelogItem(scope, description, ref string) string {
+	if scope != "" {
+		return fmt.Sprintf("- **%s:** %s (%s)", scope, description, ref)
+	}
+	return fmt.Sprintf("- %s (%s)", description, ref)
+}
+
+// conventionalTitle splits a Conventional Commits title such as
+// "feat(api)!: add paging" into its type, scope and description.
+func conventionalTitle(title string) (kind, scope, description string, ok bool) {
+	prefix, description, found := strings.Cut(title, ":")
+	if !found {
+		return "", "", "", false
+	}
+	prefix = strings.TrimSuffix(prefix, "!")
+	if open := strings.Index(prefix, "("); open >= 0 && strings.HasSuffix(prefix, ")") {
+		prefix, scope = prefix[:open], prefix[open+1:len(prefix)-1]
+	}
+	if prefix == "" || strings.ContainsAny(prefix, " ()") {
+		return "", "", "", false
+	}
+	return strings.ToLower(prefix), scope, strings.TrimSpace(description), true
+}
+
+// shortSHA abbreviates a commit SHA the way git does by default.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteChangelogPullRequests(t *testing.T) {
+	merged := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	export := Export{
+		PullRequests: []PullRequest{
+			{Repo: "api", Number: 1, Title: "Add paging", Labels: []string{"Enhancement"}, MergedAt: merged},
+			{Repo: "api", Number: 2, Title: "feat(auth): add tokens", Labels: []string{"bug"}, MergedAt: merged},
+			{Repo: "api", Number: 3, Title: "fix: handle nulls", MergedAt: merged},
+			{Repo: "api", Number: 4, Title: "feat: still open"},
+			{Repo: "web", Number: 5, Title: "Bump deps", Labels: []string{"dependencies"}, MergedAt: merged},
+			{Repo: "cli", Number: 6, Title: "Tidy up", MergedAt: merged},
+		},
+		LatestReleases: map[string]string{"api": "v1.2.0", "web": ""},
+	}
+
+	var out strings.Builder
+	if err := writeChangelog(export, &out, WriteOptions{Kind: "pull_requests"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `## api: Unreleased since v1.2.0
+
+### Features
+
+- Add paging (#1)
+
+### Fixes
+
+- **auth:** add tokens (#2)
+- handle nulls (#3)
+
+## cli
+
+### Other changes
+
+- Tidy up (#6)
+
+## web: Unreleased
+
+### Chores
+
+- Bump deps (#5)
+`
+	if out.String() != want {
+		t.Errorf("changelog:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,53 @@
+package exporter
+
+import "strings"
+
+const (
+	coAuthorTrailer = "co-authored-by:"
+	noreplyDomain   = "@users.noreply.github.com"
+)
+
+// parseCoAuthors returns the co-authors listed in the Co-authored-by
+// trailers of a commit message, as "Name <email>".
+func parseCoAuthors(message string) []string {
+	var coAuthors []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < len(coAuthorTrailer) || !strings.EqualFold(line[:len(coAuthorTrailer)], coAuthorTrailer) {
+			continue
+		}
+		if coAuthor := strings.TrimSpace(line[len(coAuthorTrailer):]); coAuthor != "" {
+			coAuthors = append(coAuthors, coAuthor)
+		}
+	}
+	return coAuthors
+}
+
+// authorEmail returns the email of a commit author, or with
+// opts.NormalizeEmails the login behind a Github noreply address, which is
+// either login@users.noreply.github.com or id+login@users.noreply.github.com.
+func (opts Options) authorEmail(email string) string {
+	if !opts.NormalizeEmails || len(email) <= len(noreplyDomain) || !strings.EqualFold(email[len(email)-len(noreplyDomain):], noreplyDomain) {
+		return email
+	}
+	login := email[:len(email)-len(noreplyDomain)]
+	if _, after, found := strings.Cut(login, "+"); found {
+		login = after
+	}
+	return login
+}
+
+// splitMessages cuts the commit messages in export down to their subject
+// line when opts.FirstLineOnly is set, moving the rest into Body if bodies
+// were requested.
+func (opts Options) splitMessages(export *Export) {
+	if !opts.FirstLineOnly {
+		return
+	}
+	for i := range export.Commits {
+		commit := &export.Commits[i]
+		subject, body, _ := strings.Cut(commit.Message, "\n")
+		commit.Message = strings.TrimSpace(subject)
+		commit.Body = opts.body(strings.TrimSpace(body))
+	}
+}
@@ -0,0 +1,193 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// KeySet holds the identities of records already written, so a run appending
+// to an existing export can skip records it already contains.
+type KeySet map[string]bool
+
+// recordKey identifies r across repositories.
+func recordKey(r record) string {
+	return r.repo() + "\x00" + r.key()
+}
+
+// LoadKeys reads the identities of the records in an ndjson or csv export of
+// opts.Kind written with opts.
+func LoadKeys(r io.Reader, opts WriteOptions) (KeySet, error) {
+	keys := KeySet{}
+	switch opts.Format {
+	case "ndjson":
+		return keys, loadNDJSONKeys(r, opts, keys)
+	case "csv":
+		return keys, loadCSVKeys(r, opts, keys)
+	default:
+		return nil, fmt.Errorf("format %s cannot be appended to", opts.Format)
+	}
+}
+
+func loadNDJSONKeys(r io.Reader, opts WriteOptions, keys KeySet) error {
+	// Undo the field map so the lines decode into the record types.
+	original := map[string]string{}
+	for from, to := range opts.Fields {
+		original[to] = from
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		data, err := renameKeys(line, original)
+		if err != nil {
+			return err
+		}
+		record, err := decodeRecord(opts.Kind, data)
+		if err != nil {
+			return err
+		}
+		keys[recordKey(record)] = true
+	}
+	return scanner.Err()
+}
+
+// loadCSVKeys reads the identities of the rows of a csv export, rebuilding
+// the key of each record from its columns the way its key method does.
+func loadCSVKeys(r io.Reader, opts WriteOptions, keys KeySet) error {
+	// A shortened SHA cannot be told apart from other commits sharing its
+	// prefix, nor matched against the full SHA of a fetched commit.
+	if opts.ShortSHA > 0 {
+		return fmt.Errorf("the commit SHAs of a csv export with shortened SHAs cannot be deduplicated")
+	}
+	header, err := csvHeader(opts)
+	if err != nil {
+		return err
+	}
+	// The csv header names the Repo and ID columns after the field map.
+	repoColumn, idColumn := slices.Index(header, columnName("Repo", opts)), slices.Index(header, columnName("ID", opts))
+	// Timeline events without an ID are keyed by the commit they reference.
+	commitColumn := -1
+	if opts.Kind == "timeline" {
+		commitColumn = slices.Index(header, columnName("CommitID", opts))
+	}
+
+	reader := opts.csvReader(r)
+	reader.FieldsPerRecord = -1
+	if _, err := reader.Read(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(row) <= max(repoColumn, idColumn, commitColumn) {
+			return fmt.Errorf("csv row has %d columns, expected %d", len(row), len(header))
+		}
+		key := row[idColumn]
+		if commitColumn >= 0 && key == "0" {
+			key = row[commitColumn]
+		}
+		keys[row[repoColumn]+"\x00"+key] = true
+	}
+}
+
+// columnName returns the name a column is written under with opts.Fields.
+func columnName(name string, opts WriteOptions) string {
+	if to, ok := opts.Fields[name]; ok {
+		return to
+	}
+	return name
+}
+
+// decodeRecord decodes the JSON encoding of a record of the given kind.
+func decodeRecord(kind string, data []byte) (record, error) {
+	switch kind {
+	case "commits":
+		return decodeAs[Commit](data)
+	case "pull_requests":
+		return decodeAs[PullRequest](data)
+	case "issues":
+		return decodeAs[Issue](data)
+	case "releases":
+		return decodeAs[Release](data)
+	case "watch", "watched":
+		return decodeAs[Watch](data)
+	case "checks":
+		return decodeAs[CheckRun](data)
+	case "deployments":
+		return decodeAs[Deployment](data)
+	case "timeline":
+		return decodeAs[TimelineEvent](data)
+	case "discussions":
+		return decodeAs[Discussion](data)
+	case "collaborators":
+		return decodeAs[Collaborator](data)
+	case "stargazers":
+		return decodeAs[Stargazer](data)
+	}
+	return nil, fmt.Errorf("unsupported kind: %s", kind)
+}
+
+func decodeAs[T record](data []byte) (record, error) {
+	var r T
+	err := json.Unmarshal(data, &r)
+	return r, err
+}
+
+// Dedupe drops the records of export whose identity is in keys and adds the
+// remaining ones, so duplicates within a run are dropped as well. It returns
+// the number of records dropped.
+func (keys KeySet) Dedupe(export *Export) int {
+	var dropped, n int
+	export.Commits, n = dedupe(keys, export.Commits)
+	dropped += n
+	export.PullRequests, n = dedupe(keys, export.PullRequests)
+	dropped += n
+	export.Issues, n = dedupe(keys, export.Issues)
+	dropped += n
+	export.Releases, n = dedupe(keys, export.Releases)
+	dropped += n
+	export.Watch, n = dedupe(keys, export.Watch)
+	dropped += n
+	export.CheckRuns, n = dedupe(keys, export.CheckRuns)
+	dropped += n
+	export.Deployments, n = dedupe(keys, export.Deployments)
+	dropped += n
+	export.Timeline, n = dedupe(keys, export.Timeline)
+	dropped += n
+	export.Discussions, n = dedupe(keys, export.Discussions)
+	dropped += n
+	export.Collaborators, n = dedupe(keys, export.Collaborators)
+	dropped += n
+	export.Stargazers, n = dedupe(keys, export.Stargazers)
+	dropped += n
+	return dropped
+}
+
+func dedupe[T record](keys KeySet, records []T) ([]T, int) {
+	kept := records[:0]
+	for _, r := range records {
+		key := recordKey(r)
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+		kept = append(kept, r)
+	}
+	return kept, len(records) - len(kept)
+}
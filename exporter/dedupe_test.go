@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// appendRun appends the records of batch that are not in existing to it, the
+// way an --append --dedupe-across-files run does, and returns the result.
+func appendRun(t *testing.T, existing []byte, batch Export, opts WriteOptions) []byte {
+	t.Helper()
+	opts.Append = len(existing) > 0
+	keys, err := LoadKeys(bytes.NewReader(existing), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.Dedupe(&batch)
+
+	out := bytes.NewBuffer(append([]byte(nil), existing...))
+	stream, err := NewStreamWriter(out, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Write(batch); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func testCommits(n int) Export {
+	var export Export
+	date := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		export.Commits = append(export.Commits, Commit{
+			Repo:   "octo/repo",
+			SHA:    fmt.Sprintf("%040x", i+1),
+			Author: "octo",
+			Date:   date.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	return export
+}
+
+func TestAppendDedupeAcrossFiles(t *testing.T) {
+	for _, format := range []string{"ndjson", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			opts := WriteOptions{Format: format, Kind: "commits"}
+			first := appendRun(t, nil, testCommits(10), opts)
+			second := appendRun(t, first, testCommits(10), opts)
+			if !bytes.Equal(first, second) {
+				t.Errorf("second run changed the export from %d to %d lines",
+					strings.Count(string(first), "\n"), strings.Count(string(second), "\n"))
+			}
+
+			third := appendRun(t, second, testCommits(12), opts)
+			if got, want := strings.Count(string(third), "\n"), strings.Count(string(second), "\n")+2; got != want {
+				t.Errorf("third run wrote %d lines, want %d", got, want)
+			}
+		})
+	}
+}
+
+// testTimeline returns n timeline events, every other one a commit without an
+// event ID.
+func testTimeline(n int) Export {
+	var export Export
+	for i := 0; i < n; i++ {
+		event := TimelineEvent{Repo: "octo/repo", Number: 1, Event: "labeled", Actor: "octo", Date: testDate}
+		if i%2 == 0 {
+			event.ID = int64(i + 1)
+		} else {
+			event.Event, event.CommitID = "committed", fmt.Sprintf("%040x", i+1)
+		}
+		export.Timeline = append(export.Timeline, event)
+	}
+	return export
+}
+
+func TestAppendDedupeTimeline(t *testing.T) {
+	for _, format := range []string{"ndjson", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			opts := WriteOptions{Format: format, Kind: "timeline"}
+			first := appendRun(t, nil, testTimeline(6), opts)
+			second := appendRun(t, first, testTimeline(6), opts)
+			if !bytes.Equal(first, second) {
+				t.Errorf("second run changed the export from %d to %d lines",
+					strings.Count(string(first), "\n"), strings.Count(string(second), "\n"))
+			}
+		})
+	}
+}
+
+func TestLoadKeysShortSHA(t *testing.T) {
+	opts := WriteOptions{Format: "csv", Kind: "commits"}
+	existing := appendRun(t, nil, testCommits(2), opts)
+	opts.ShortSHA = 7
+	if _, err := LoadKeys(bytes.NewReader(existing), opts); err == nil {
+		t.Error("loaded the keys of a csv export with shortened SHAs")
+	}
+}
@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// The REST API does not cover discussions, they are listed over GraphQL,
+// newest first.
+const discussionsQuery = `query($owner: String!, $name: String!, $first: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    discussions(first: $first, after: $cursor, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes {
+        number
+        title
+        body
+        createdAt
+        category { name }
+        author { login }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+// fetchDiscussions returns the discussions of repo created in the export
+// window. Paging stops at the first one created before opts.Since.
+func fetchDiscussions(ctx context.Context, client *github.Client, progress *progress, opts Options, repo *github.Repository) ([]Discussion, error) {
+	var discussions []Discussion
+
+	variables := map[string]any{"owner": repo.GetOwner().GetLogin(), "name": repo.GetName(), "first": opts.perPage()}
+	for page := 1; ; page++ {
+		var data struct {
+			Repository struct {
+				Discussions struct {
+					Nodes []struct {
+						Number    int
+						Title     string
+						Body      string
+						CreatedAt time.Time
+						Category  struct{ Name string }
+						Author    *struct{ Login string }
+					}
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   string
+					}
+				}
+			}
+		}
+		resp, err := graphQL(ctx, client, discussionsQuery, variables, &data)
+		progress.observe(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		result := data.Repository.Discussions
+		opts.Stats.page(repo.GetFullName(), "discussions", nil, page, len(result.Nodes))
+		for _, node := range result.Nodes {
+			if !opts.Since.IsZero() && node.CreatedAt.Before(opts.Since) {
+				return discussions, nil
+			}
+			if !opts.inWindow(node.CreatedAt) {
+				continue
+			}
+			discussion := Discussion{
+				Repo:     repo.GetName(),
+				Number:   node.Number,
+				Title:    node.Title,
+				Category: node.Category.Name,
+				Date:     node.CreatedAt,
+				Body:     opts.body(node.Body),
+			}
+			if node.Author != nil {
+				discussion.Author = node.Author.Login
+			}
+			discussions = append(discussions, discussion)
+		}
+
+		if !result.PageInfo.HasNextPage || !opts.belowMaxPages(page, "discussions of "+repo.GetFullName()) {
+			return discussions, nil
+		}
+		variables["cursor"] = result.PageInfo.EndCursor
+	}
+}
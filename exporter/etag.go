@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ETagCache is an http.RoundTripper that makes GET requests conditional on
+// the ETag of the previous response for the same URL. Github answers
+// unchanged resources with 304 Not Modified, which does not count against the
+// rate limit, and the cached response is served in its place.
+//
+// Entries are kept in memory and written to a single JSON file by Save. An
+// entry is replaced whenever Github returns a new ETag for its URL; delete the
+// file to drop the cache entirely.
+type ETagCache struct {
+	// Base is the transport used to make requests. http.DefaultTransport is
+	// used when nil.
+	Base http.RoundTripper
+
+	path    string
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+type etagEntry struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// NewETagCache returns a cache persisted at path, loading any entries stored
+// there by an earlier run.
+func NewETagCache(path string, base http.RoundTripper) (*ETagCache, error) {
+	c := &ETagCache{Base: base, path: path, entries: map[string]etagEntry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the cache entries to the cache file.
+func (c *ETagCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(c.path, data)
+}
+
+func (c *ETagCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := c.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet {
+		return base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	c.mu.Lock()
+	entry, cached := c.entries[key]
+	c.mu.Unlock()
+
+	if cached {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+
+		header := entry.Header.Clone()
+		for name, values := range resp.Header {
+			if strings.HasPrefix(name, "X-Ratelimit-") {
+				header[name] = values
+			}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Request:       req,
+		}, nil
+
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		c.mu.Lock()
+		c.entries[key] = etagEntry{ETag: resp.Header.Get("ETag"), Header: resp.Header.Clone(), Body: body}
+		c.mu.Unlock()
+	}
+	return resp, nil
+}
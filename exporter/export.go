@@ -0,0 +1,407 @@
+// Package exporter fetches GitHub user activity and writes it in a number of
+// output formats. It is the library behind the github-exporter command and can
+// be embedded in other programs.
+package exporter
+
+import (
+	"strconv"
+	"time"
+)
+
+// SchemaVersion is the version of the layout of exports, written to the
+// json format as meta.schema_version. It is raised whenever a change to the
+// records would break readers of older exports, such as a removed or renamed
+// field or column; new fields leave it alone.
+const SchemaVersion = 1
+
+// Kinds lists the kinds of records an export can hold.
+var Kinds = []string{"commits", "pull_requests", "issues", "releases", "watched", "checks", "deployments", "timeline", "discussions", "collaborators", "stargazers"}
+
+type Export struct {
+	Meta          Meta            `json:"meta"`
+	Commits       []Commit        `json:"commits"`
+	PullRequests  []PullRequest   `json:"pull_requests"`
+	Issues        []Issue         `json:"issues"`
+	Releases      []Release       `json:"releases"`
+	Watch         []Watch         `json:"watch"`
+	CheckRuns     []CheckRun      `json:"check_runs"`
+	Deployments   []Deployment    `json:"deployments"`
+	Timeline      []TimelineEvent `json:"timeline"`
+	Discussions   []Discussion    `json:"discussions"`
+	Collaborators []Collaborator  `json:"collaborators"`
+	Stargazers    []Stargazer     `json:"stargazers"`
+	Repositories  []Repository    `json:"repositories,omitempty"`
+	// LatestReleases maps the repositories walked with
+	// Options.SinceLastRelease to the tag of their latest release, the one
+	// their records came after; it is empty for a repository without one.
+	LatestReleases map[string]string `json:"latest_releases,omitempty"`
+	// WatchHistory is only set when Options.WatchHistory asks for it.
+	WatchHistory []WatchPeriod `json:"watch_history,omitempty"`
+	// Stats is only set when Options.Stats asks for it, for debugging.
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Repository pins the state of a walked repository at export time.
+// HeadSHA is only looked up on request.
+type Repository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+	HeadSHA       string `json:"head_sha,omitempty"`
+}
+
+// Meta describes how an export was produced, making archived exports
+// self-describing. Fetch fills in everything but Version, Flags and RateLimit,
+// which are up to the caller.
+type Meta struct {
+	SchemaVersion int               `json:"schema_version"`
+	Login         string            `json:"login"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Version       string            `json:"version,omitempty"`
+	Kind          string            `json:"kind"`
+	Mode          string            `json:"mode,omitempty"`
+	Flags         map[string]string `json:"flags,omitempty"`
+	// Partial is set when the export was interrupted and only holds the
+	// records fetched up to that point.
+	Partial bool `json:"partial,omitempty"`
+	// Members lists the logins of a team export, whose records carry the
+	// login they were fetched for in Member. Login is empty then.
+	Members []string `json:"members,omitempty"`
+	// Dropped is the number of records dropped by strict validation.
+	Dropped int `json:"dropped,omitempty"`
+	// RateLimit is the rate limit usage of the export per rate limit
+	// resource, as recorded by a RateTracker.
+	RateLimit map[string]RateUsage `json:"rate_limit,omitempty"`
+}
+
+type Commit struct {
+	Repo        string `json:"repo"`
+	SHA         string `json:"sha"`
+	Message     string `json:"message"`
+	Body        string `json:"body,omitempty"`
+	Author      string `json:"author"`
+	AuthorEmail string `json:"author_email,omitempty"`
+	// AuthorLogin and CommitterLogin are the Github accounts of the git
+	// author and committer, empty when their email matches no account. The
+	// committer differs from the author for rebased and cherry-picked
+	// commits. Events mode only knows the author's name and email.
+	AuthorLogin        string    `json:"author_login,omitempty"`
+	CommitterName      string    `json:"committer_name,omitempty"`
+	CommitterLogin     string    `json:"committer_login,omitempty"`
+	Member             string    `json:"member,omitempty"`
+	Date               time.Time `json:"date"`
+	Verified           bool      `json:"verified"`
+	VerificationReason string    `json:"verification_reason,omitempty"`
+	// IsMerge is set for commits with more than one parent. Events mode
+	// does not know the parents and leaves it unset.
+	IsMerge   bool         `json:"is_merge"`
+	CoAuthors []string     `json:"co_authors,omitempty"`
+	Files     []CommitFile `json:"files,omitempty"`
+}
+
+// CommitFile is a file changed by a commit. Patch holds the diff inline
+// unless the patches were written out with WritePatches, in which case
+// PatchFile points at the file holding it.
+type CommitFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Patch     string `json:"patch,omitempty"`
+	PatchFile string `json:"patch_file,omitempty"`
+}
+
+// PullRequest is a pull request opened or acted on. RequestedReviewers and
+// RequestedTeams list the logins and team slugs whose review is still
+// pending; Github drops a reviewer from them once they have reviewed. Search
+// mode leaves them empty.
+type PullRequest struct {
+	Repo               string    `json:"repo"`
+	Number             int       `json:"number"`
+	Title              string    `json:"title"`
+	State              string    `json:"state"`
+	Draft              bool      `json:"draft"`
+	Author             string    `json:"author"`
+	Member             string    `json:"member,omitempty"`
+	Action             string    `json:"action"`
+	Date               time.Time `json:"date"`
+	Body               string    `json:"body,omitempty"`
+	RequestedReviewers []string  `json:"requested_reviewers,omitempty"`
+	RequestedTeams     []string  `json:"requested_teams,omitempty"`
+	Labels             []string  `json:"labels,omitempty"`
+	// MergedAt is zero while the pull request is not merged.
+	MergedAt time.Time `json:"merged_at,omitempty"`
+	// Commits are only listed on request, see Options.WithCommits.
+	Commits []PullRequestCommit `json:"commits,omitempty"`
+}
+
+// PullRequestCommit is a commit belonging to a pull request.
+type PullRequestCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+}
+
+type Issue struct {
+	Repo   string    `json:"repo"`
+	Number int       `json:"number"`
+	Title  string    `json:"title"`
+	State  string    `json:"state"`
+	Author string    `json:"author"`
+	Member string    `json:"member,omitempty"`
+	Action string    `json:"action"`
+	Date   time.Time `json:"date"`
+	Body   string    `json:"body,omitempty"`
+}
+
+type Release struct {
+	Repo    string    `json:"repo"`
+	TagName string    `json:"tag_name"`
+	Name    string    `json:"name"`
+	Author  string    `json:"author"`
+	Member  string    `json:"member,omitempty"`
+	Action  string    `json:"action"`
+	Date    time.Time `json:"date"`
+	// Draft releases are only listed for tokens with push access.
+	Draft      bool           `json:"draft"`
+	Prerelease bool           `json:"prerelease"`
+	Assets     []ReleaseAsset `json:"assets,omitempty"`
+}
+
+// ReleaseAsset is a file attached to a release. Path is the file it was
+// downloaded to by DownloadAssets.
+type ReleaseAsset struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Size          int    `json:"size"`
+	DownloadURL   string `json:"download_url"`
+	DownloadCount int    `json:"download_count"`
+	Path          string `json:"path,omitempty"`
+
+	// repo is the owner/name of the repository of the release, to
+	// download the asset from.
+	repo string
+}
+
+type Watch struct {
+	Repo   string    `json:"repo"`
+	Author string    `json:"author"`
+	Member string    `json:"member,omitempty"`
+	Action string    `json:"action"`
+	Date   time.Time `json:"date"`
+}
+
+// CheckRun is the outcome of a CI check run on a commit. Conclusion is empty
+// while the check is still running.
+type CheckRun struct {
+	Repo        string    `json:"repo"`
+	ID          int64     `json:"id"`
+	SHA         string    `json:"sha"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	CompletedAt time.Time `json:"completed_at"`
+	Member      string    `json:"member,omitempty"`
+}
+
+// Deployment is a deployment of a ref to one of a repository's environments.
+type Deployment struct {
+	Repo        string    `json:"repo"`
+	ID          int64     `json:"id"`
+	Environment string    `json:"environment"`
+	Ref         string    `json:"ref"`
+	SHA         string    `json:"sha"`
+	Creator     string    `json:"creator"`
+	Member      string    `json:"member,omitempty"`
+	Date        time.Time `json:"date"`
+}
+
+// TimelineEvent is an event in the timeline of an issue or pull request:
+// a comment, label change, assignment, referenced commit, review, etc.
+// Label, Assignee, CommitID and State are only set for the events they apply
+// to.
+type TimelineEvent struct {
+	Repo     string    `json:"repo"`
+	Number   int       `json:"number"`
+	ID       int64     `json:"id,omitempty"`
+	Event    string    `json:"event"`
+	Actor    string    `json:"actor"`
+	Member   string    `json:"member,omitempty"`
+	Date     time.Time `json:"date"`
+	Label    string    `json:"label,omitempty"`
+	Assignee string    `json:"assignee,omitempty"`
+	CommitID string    `json:"commit_id,omitempty"`
+	State    string    `json:"state,omitempty"`
+	Body     string    `json:"body,omitempty"`
+}
+
+// Discussion is a Github Discussions thread. Author is empty for deleted
+// accounts.
+type Discussion struct {
+	Repo     string    `json:"repo"`
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	Category string    `json:"category"`
+	Author   string    `json:"author"`
+	Member   string    `json:"member,omitempty"`
+	Date     time.Time `json:"date"`
+	Body     string    `json:"body,omitempty"`
+}
+
+// Collaborator is a user with access to a repository and their role in it
+// (admin, maintain, write, triage or read, or a custom role). Collaborators
+// carry no date.
+type Collaborator struct {
+	Repo       string `json:"repo"`
+	Login      string `json:"login"`
+	Permission string `json:"permission"`
+	Member     string `json:"member,omitempty"`
+}
+
+// Stargazer is a user who starred a repository, and when.
+type Stargazer struct {
+	Repo      string    `json:"repo"`
+	Login     string    `json:"login"`
+	Member    string    `json:"member,omitempty"`
+	StarredAt time.Time `json:"starred_at"`
+}
+
+// record is implemented by every exported record type. key identifies the
+// record within its repository: the SHA of a commit, the number of an issue
+// or pull request, the tag of a release.
+type record interface {
+	repo() string
+	key() string
+	author() string
+	member() string
+	date() time.Time
+	required() []string
+}
+
+func (c Commit) repo() string    { return c.Repo }
+func (c Commit) key() string     { return c.SHA }
+func (c Commit) author() string  { return c.Author }
+func (c Commit) member() string  { return c.Member }
+func (c Commit) date() time.Time { return c.Date }
+
+func (pr PullRequest) repo() string    { return pr.Repo }
+func (pr PullRequest) key() string     { return strconv.Itoa(pr.Number) }
+func (pr PullRequest) author() string  { return pr.Author }
+func (pr PullRequest) member() string  { return pr.Member }
+func (pr PullRequest) date() time.Time { return pr.Date }
+
+func (i Issue) repo() string    { return i.Repo }
+func (i Issue) key() string     { return strconv.Itoa(i.Number) }
+func (i Issue) author() string  { return i.Author }
+func (i Issue) member() string  { return i.Member }
+func (i Issue) date() time.Time { return i.Date }
+
+func (r Release) repo() string    { return r.Repo }
+func (r Release) key() string     { return r.TagName }
+func (r Release) author() string  { return r.Author }
+func (r Release) member() string  { return r.Member }
+func (r Release) date() time.Time { return r.Date }
+
+func (w Watch) repo() string    { return w.Repo }
+func (w Watch) key() string     { return "" }
+func (w Watch) author() string  { return w.Author }
+func (w Watch) member() string  { return w.Member }
+func (w Watch) date() time.Time { return w.Date }
+
+func (cr CheckRun) repo() string    { return cr.Repo }
+func (cr CheckRun) key() string     { return strconv.FormatInt(cr.ID, 10) }
+func (cr CheckRun) author() string  { return "" }
+func (cr CheckRun) member() string  { return cr.Member }
+func (cr CheckRun) date() time.Time { return cr.CompletedAt }
+
+func (d Deployment) repo() string    { return d.Repo }
+func (d Deployment) key() string     { return strconv.FormatInt(d.ID, 10) }
+func (d Deployment) author() string  { return d.Creator }
+func (d Deployment) member() string  { return d.Member }
+func (d Deployment) date() time.Time { return d.Date }
+
+// Commits referenced in a timeline have no event ID and are identified by
+// their SHA instead.
+func (e TimelineEvent) repo() string { return e.Repo }
+func (e TimelineEvent) key() string {
+	if e.ID == 0 {
+		return e.CommitID
+	}
+	return strconv.FormatInt(e.ID, 10)
+}
+func (e TimelineEvent) author() string  { return e.Actor }
+func (e TimelineEvent) member() string  { return e.Member }
+func (e TimelineEvent) date() time.Time { return e.Date }
+
+func (d Discussion) repo() string    { return d.Repo }
+func (d Discussion) key() string     { return strconv.Itoa(d.Number) }
+func (d Discussion) author() string  { return d.Author }
+func (d Discussion) member() string  { return d.Member }
+func (d Discussion) date() time.Time { return d.Date }
+
+// Count returns the number of records of the given kind in export.
+func Count(export Export, kind string) int {
+	return len(records(export, kind))
+}
+
+func (c Collaborator) repo() string    { return c.Repo }
+func (c Collaborator) key() string     { return c.Login }
+func (c Collaborator) author() string  { return c.Login }
+func (c Collaborator) member() string  { return c.Member }
+func (c Collaborator) date() time.Time { return time.Time{} }
+
+func (s Stargazer) repo() string    { return s.Repo }
+func (s Stargazer) key() string     { return s.Login }
+func (s Stargazer) author() string  { return s.Login }
+func (s Stargazer) member() string  { return s.Member }
+func (s Stargazer) date() time.Time { return s.StarredAt }
+
+// records returns the records of the given kind in export.
+func records(export Export, kind string) []record {
+	var records []record
+	switch kind {
+	case "commits":
+		for _, commit := range export.Commits {
+			records = append(records, commit)
+		}
+	case "pull_requests":
+		for _, pr := range export.PullRequests {
+			records = append(records, pr)
+		}
+	case "issues":
+		for _, issue := range export.Issues {
+			records = append(records, issue)
+		}
+	case "releases":
+		for _, release := range export.Releases {
+			records = append(records, release)
+		}
+	case "watch", "watched":
+		for _, watch := range export.Watch {
+			records = append(records, watch)
+		}
+	case "checks":
+		for _, run := range export.CheckRuns {
+			records = append(records, run)
+		}
+	case "deployments":
+		for _, deployment := range export.Deployments {
+			records = append(records, deployment)
+		}
+	case "timeline":
+		for _, event := range export.Timeline {
+			records = append(records, event)
+		}
+	case "discussions":
+		for _, discussion := range export.Discussions {
+			records = append(records, discussion)
+		}
+	case "collaborators":
+		for _, collaborator := range export.Collaborators {
+			records = append(records, collaborator)
+		}
+	case "stargazers":
+		for _, stargazer := range export.Stargazers {
+			records = append(records, stargazer)
+		}
+	}
+	return records
+}
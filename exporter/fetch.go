@@ -0,0 +1,1411 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// Options controls what Fetch retrieves.
+type Options struct {
+	// Kind of data to export (commits, pull_requests, issues, releases,
+	// watched, checks, deployments, timeline, discussions, collaborators,
+	// stargazers).
+	Kind string
+	// Mode selects the data source. "events" uses the Github events API,
+	// "search" the search API (issues and pull_requests only), anything else
+	// walks the authenticated user's repositories.
+	Mode string
+	// Author is the login whose activity is exported. It defaults to the
+	// authenticated user and is required when authenticating as a Github
+	// App, which has no user.
+	Author string
+	// Members exports the combined activity of several logins instead of
+	// Author's, one after the other. Every record is tagged with the login
+	// it was fetched for.
+	Members []string
+	// member is the login of Members currently being fetched.
+	member string
+	// Repos limits the export to these repositories instead of walking the
+	// user's own repositories. They are given as owner/name, as a URL, or
+	// as a bare name owned by the authenticated user. Renamed and
+	// transferred repositories are followed to their new location.
+	Repos []string
+	// FailFast stops the export at the first repository of Repos that does
+	// not exist or is not accessible, which is otherwise skipped with a
+	// warning.
+	FailFast bool
+	// WatchHistory turns the watch events of the events mode into the
+	// watch periods of Export.WatchHistory. It needs the records held in
+	// the export and has no effect with Stream.
+	WatchHistory bool
+	// Number is the issue or pull request whose timeline the timeline kind
+	// exports, in the single repository of Repos.
+	Number int
+	// WithRepos adds the walked repositories and their default branch to
+	// the export. WithHeadSHA also records the commit the default branch
+	// points to, at the cost of one request per repository.
+	WithRepos   bool
+	WithHeadSHA bool
+	// InstallationRepos walks the repositories of the Github App
+	// installation instead of those owned by the authenticated user.
+	InstallationRepos bool
+	// Query is a raw search query used by search mode instead of building
+	// one from Author, Kind, Since and Until.
+	Query string
+	// WithPatch fetches the changed files and their patches for every
+	// commit. This costs one extra request per commit and is only supported
+	// for the commits kind outside of events mode.
+	WithPatch bool
+	// WithCommits lists the commits of every pull request into its
+	// Commits, at the cost of at least one request per pull request.
+	WithCommits bool
+	// WithBody includes the body of issues and pull requests, and of
+	// commits with FirstLineOnly.
+	WithBody bool
+	// FirstLineOnly keeps only the subject line of commit messages in
+	// Message. The rest of the message goes into Body with WithBody.
+	FirstLineOnly bool
+	// SinceLastRelease exports, per repository, the commits made or pull
+	// requests merged since its latest release was published instead of
+	// those since Since, recording the release in Export.LatestReleases.
+	// All of them are exported for repositories without a release.
+	SinceLastRelease bool
+	// Branch lists the commits of this branch, or any other ref, instead of
+	// those of the default branch, for the commits and checks kinds when
+	// walking repositories.
+	Branch string
+	// Path keeps only the commits touching this file or directory, for the
+	// commits and checks kinds when walking repositories.
+	Path string
+	// NormalizeEmails replaces Github noreply commit emails with the login
+	// they belong to, so a person's commits share one AuthorEmail.
+	NormalizeEmails bool
+	// WithCoAuthors parses the Co-authored-by trailers of commit messages.
+	WithCoAuthors bool
+
+	// MergedOnly keeps only merged pull requests, matching Since and Until
+	// against their merge date rather than their creation date. The REST
+	// listings cannot filter by merge, so this is done client-side; search
+	// mode asks for is:merged instead.
+	MergedOnly bool
+	// ExcludeDrafts drops draft pull requests and releases, DraftsOnly
+	// keeps nothing but draft pull requests and releases.
+	ExcludeDrafts bool
+	DraftsOnly    bool
+	// ExcludePrereleases drops the releases marked as prereleases.
+	ExcludePrereleases bool
+	// OnlyMine keeps only the pull requests and issues opened by the
+	// exported login when walking repositories, which otherwise lists
+	// everyone's. Commits are always those of the login, and the events and
+	// search modes only ever find the login's activity.
+	OnlyMine bool
+	// Labels keeps only the pull requests and issues carrying all of these
+	// labels.
+	Labels []string
+	// ExcludeMerges drops merge commits, those with more than one parent.
+	// Events mode does not know the parents of commits and keeps them all.
+	ExcludeMerges bool
+
+	// Strict checks every record for empty required fields, such as a
+	// missing author, and fails the export on the first invalid record.
+	// With StrictDrop invalid records are dropped and counted in
+	// Meta.Dropped instead.
+	Strict     bool
+	StrictDrop bool
+	// dropped counts the records dropped by StrictDrop.
+	dropped *int
+
+	// Anonymizer, when set, replaces the logins, names and emails in the
+	// export with pseudonyms.
+	Anonymizer *Anonymizer
+
+	// ExcludeBots drops records authored by bots: App accounts, whose
+	// login ends in [bot], and the logins listed in Bots.
+	ExcludeBots bool
+	Bots        []string
+
+	// Stream, when set, receives the records in batches as they are
+	// fetched, a page or a repository at a time, instead of them piling up
+	// in the Export returned by Fetch. This keeps memory flat on large
+	// accounts. Batches carry no Meta.
+	Stream func(batch Export) error
+
+	// Logger receives progress messages. Logging is disabled when nil.
+	Logger *log.Logger
+	// Warnings receives warnings about the export, such as a renamed
+	// repository. They are dropped when nil.
+	Warnings *log.Logger
+
+	// EmptyRetries is how often an export that came back without any
+	// records is fetched again, waiting EmptyRetryDelay in between. This
+	// works around the events API lagging behind recent activity.
+	EmptyRetries    int
+	EmptyRetryDelay time.Duration
+
+	// Stats, when set, collects the number of pages and records of every
+	// listing and is attached to the export.
+	Stats *Stats
+	// PerPage is the number of items requested per page, between 1 and 100
+	// (the default). The repository listing always uses 100.
+	PerPage int
+	// MaxPages caps the number of pages fetched by every paginated listing,
+	// guarding the rate limit against runaway pagination. Zero means no
+	// limit.
+	MaxPages int
+
+	// Since and Until limit the export to records created in that window.
+	// A zero value leaves that end of the window open.
+	Since time.Time
+	Until time.Time
+
+	// Repository filters, applied before any activity is fetched.
+	MinStars        int
+	PushedSince     time.Time
+	ExcludeForks    bool
+	ExcludeArchived bool
+
+	// RepoCache is a file caching the repository list between runs. The
+	// cached list is reused while it is younger than RepoCacheTTL, unless
+	// RefreshRepoCache is set.
+	RepoCache        string
+	RepoCacheTTL     time.Duration
+	RefreshRepoCache bool
+}
+
+// body returns b when bodies were requested and "" otherwise.
+func (opts Options) body(b string) string {
+	if opts.WithBody {
+		return b
+	}
+	return ""
+}
+
+// wantPullRequest reports whether a pull request created at created and
+// merged at merged, which is zero while it is not, is exported. With
+// opts.MergedOnly only merged pull requests are, and the Since/Until window
+// applies to their merge date.
+func (opts Options) wantPullRequest(created, merged time.Time) bool {
+	if opts.MergedOnly {
+		return !merged.IsZero() && opts.inWindow(merged)
+	}
+	return opts.inWindow(created)
+}
+
+// inWindow reports whether t lies within the Since/Until window.
+func (opts Options) inWindow(t time.Time) bool {
+	if !opts.Since.IsZero() && t.Before(opts.Since) {
+		return false
+	}
+	if !opts.Until.IsZero() && t.After(opts.Until) {
+		return false
+	}
+	return true
+}
+
+// wantRepo reports whether records of the repository fullName are exported,
+// which is all of them unless Repos is set.
+func (opts Options) wantRepo(fullName string) bool {
+	if len(opts.Repos) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(opts.Repos, func(repo string) bool { return strings.EqualFold(repo, fullName) })
+}
+
+// wantDraft reports whether a pull request or release with the given draft
+// state passes the draft filters.
+func (opts Options) wantDraft(draft bool) bool {
+	return !(opts.ExcludeDrafts && draft) && !(opts.DraftsOnly && !draft)
+}
+
+// wantRelease reports whether release passes the draft and prerelease
+// filters.
+func (opts Options) wantRelease(release *github.RepositoryRelease) bool {
+	return opts.wantDraft(release.GetDraft()) && !(opts.ExcludePrereleases && release.GetPrerelease())
+}
+
+// hasLabels reports whether an issue or pull request with labels carries
+// every label of opts.Labels. Labels are compared case-insensitively, like
+// Github does.
+func (opts Options) hasLabels(labels []*github.Label) bool {
+	for _, want := range opts.Labels {
+		if !slices.ContainsFunc(labels, func(label *github.Label) bool { return strings.EqualFold(label.GetName(), want) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// filter applies the record filters to the records of export. It must see
+// every record exactly once, as anonymizing or splitting a message twice
+// garbles it.
+func (opts Options) filter(export *Export) error {
+	opts.tagMember(export)
+	opts.dropBots(export)
+	if err := opts.validate(export); err != nil {
+		return err
+	}
+	opts.Anonymizer.anonymize(export)
+	opts.splitMessages(export)
+	return nil
+}
+
+// flush filters the records collected so far, hands them to opts.Stream and
+// drops them from export. Without a Stream it does nothing; the records are
+// filtered once the fetch is done, by finish.
+func (opts Options) flush(export *Export) error {
+	if opts.Stream == nil {
+		return nil
+	}
+	if err := opts.filter(export); err != nil {
+		return err
+	}
+	batch := Export{
+		Commits:      export.Commits,
+		PullRequests: export.PullRequests,
+		Issues:       export.Issues,
+		Releases:     export.Releases,
+		Watch:        export.Watch,
+		CheckRuns:    export.CheckRuns,
+		Deployments:  export.Deployments,
+		Timeline:     export.Timeline,
+		Discussions:  export.Discussions,
+
+		Collaborators: export.Collaborators,
+		Stargazers:    export.Stargazers,
+	}
+	export.Commits, export.PullRequests, export.Issues, export.Releases, export.Watch, export.CheckRuns = nil, nil, nil, nil, nil, nil
+	export.Deployments, export.Timeline, export.Discussions, export.Collaborators, export.Stargazers = nil, nil, nil, nil, nil
+	if len(records(batch, opts.Kind)) == 0 {
+		return nil
+	}
+	return opts.Stream(batch)
+}
+
+// Fetch retrieves the authenticated user's activity according to opts. When
+// opts.Stream is set the returned Export only carries the Meta. If ctx is
+// canceled the records fetched so far are returned, or streamed, along with
+// the context's error.
+func Fetch(ctx context.Context, client *github.Client, opts Options) (Export, error) {
+	var err error
+	if opts.Repos, err = normalizeRepos(ctx, client, opts.Repos); err != nil {
+		return Export{}, err
+	}
+	dropped := 0
+	opts.dropped = &dropped
+	export, err := fetchMembers(ctx, client, opts)
+	export.Meta.Dropped = dropped
+	if opts.WatchHistory {
+		export.WatchHistory = WatchHistory(export.Watch)
+	}
+	if opts.Stats != nil {
+		opts.Stats.markCapped(opts.MaxPages)
+		export.Stats = opts.Stats
+	}
+	opts.Anonymizer.anonymizeMeta(&export)
+	return export, err
+}
+
+// fetchMembers retrieves the activity of every login in opts.Members, or of
+// opts.Author when there are none.
+func fetchMembers(ctx context.Context, client *github.Client, opts Options) (Export, error) {
+	if len(opts.Members) == 0 {
+		// The login is resolved once up front rather than per fetch
+		// attempt.
+		login := opts.Author
+		if login == "" {
+			var err error
+			if login, err = Login(ctx, client); err != nil {
+				return Export{}, err
+			}
+		}
+		return fetchLogin(ctx, client, login, opts)
+	}
+
+	// Members are fetched one after the other rather than in parallel, so
+	// the rate limit is drawn down by one export at a time.
+	export := Export{Meta: newMeta("", opts)}
+	for _, member := range opts.Members {
+		opts.member = member
+		part, err := fetchLogin(ctx, client, member, opts)
+		export.add(part)
+		if err != nil {
+			return export, err
+		}
+	}
+	return export, nil
+}
+
+// fetchLogin retrieves the activity of login, fetching again while the export
+// comes back empty if opts asks for it.
+func fetchLogin(ctx context.Context, client *github.Client, login string, opts Options) (Export, error) {
+	streamed := 0
+	if stream := opts.Stream; stream != nil {
+		opts.Stream = func(batch Export) error {
+			streamed += len(records(batch, opts.Kind))
+			return stream(batch)
+		}
+	}
+
+	export, err := fetch(ctx, client, login, opts)
+	for attempt := 0; err == nil && attempt < opts.EmptyRetries && streamed == 0 && len(records(export, opts.Kind)) == 0; attempt++ {
+		select {
+		case <-ctx.Done():
+			return export, ctx.Err()
+		case <-time.After(opts.EmptyRetryDelay):
+		}
+		export, err = fetch(ctx, client, login, opts)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			if ferr := opts.finish(&export); ferr != nil {
+				return export, ferr
+			}
+		}
+		return export, err
+	}
+	return export, opts.finish(&export)
+}
+
+// finish filters the records left in export at the end of a fetch, and
+// streams them when streaming.
+func (opts Options) finish(export *Export) error {
+	if opts.Stream == nil {
+		return opts.filter(export)
+	}
+	return opts.flush(export)
+}
+
+// fetch retrieves the activity of login according to opts.
+func fetch(ctx context.Context, client *github.Client, login string, opts Options) (Export, error) {
+	if opts.Kind == "timeline" {
+		return fetchTimeline(ctx, client, login, opts)
+	}
+	switch opts.Mode {
+	case "events":
+		return fetchGitHubEvents(ctx, client, login, opts)
+	case "search":
+		return fetchGitHubSearch(ctx, client, login, opts)
+	default:
+		return fetchGitHubData(ctx, client, login, opts)
+	}
+}
+
+func fetchGitHubData(ctx context.Context, client *github.Client, username string, opts Options) (Export, error) {
+	export := Export{}
+	export.Meta = newMeta(username, opts)
+
+	// Watched repositories are listed for the user rather than per
+	// repository.
+	if opts.Kind == "watched" {
+		var err error
+		export.Watch, err = fetchWatched(ctx, client, username, opts)
+		return export, err
+	}
+
+	repos, err := Repositories(ctx, client, opts)
+	if err != nil {
+		return export, err
+	}
+
+	if opts.WithRepos {
+		if export.Repositories, err = snapshotRepos(ctx, client, repos, opts); err != nil {
+			return export, err
+		}
+	}
+
+	progress := newProgress(opts.Logger, opts.Warnings, len(repos))
+	for _, repo := range repos {
+		opts := opts
+		if opts.SinceLastRelease {
+			tag, published, err := latestRelease(ctx, client, progress, repo)
+			if err != nil {
+				return export, err
+			}
+			if export.LatestReleases == nil {
+				export.LatestReleases = map[string]string{}
+			}
+			export.LatestReleases[*repo.Name] = tag
+			opts.Since = published
+			// Only merged pull requests made it into the next release.
+			opts.MergedOnly = true
+		}
+
+		opt := &github.CommitsListOptions{
+			SHA:         opts.Branch,
+			Path:        opts.Path,
+			Author:      username,
+			Since:       opts.Since,
+			Until:       opts.Until,
+			ListOptions: github.ListOptions{PerPage: opts.perPage()},
+		}
+
+		switch opts.Kind {
+		case "commits":
+			// Fetch commits
+			for page := 1; ; page++ {
+				commits, resp, err := client.Repositories.ListCommits(ctx, *repo.Owner.Login, *repo.Name, opt)
+				progress.observe(resp)
+				if err != nil {
+					return export, opts.commitsError(err, resp, repo)
+				}
+				if page == 1 {
+					if err := followRename(ctx, client, opts, repo, resp); err != nil {
+						return export, err
+					}
+				}
+				opts.Stats.page(repo.GetFullName(), "commits", resp, page, len(commits))
+				for _, commit := range commits {
+					isMerge := len(commit.Parents) > 1
+					if opts.ExcludeMerges && isMerge {
+						continue
+					}
+					c := Commit{
+						Repo:    *repo.Name,
+						SHA:     *commit.SHA,
+						Message: *commit.Commit.Message,
+						Author:  *commit.Commit.Author.Name,
+						Date:    commit.Commit.Author.Date.Time,
+
+						AuthorEmail:    opts.authorEmail(commit.GetCommit().GetAuthor().GetEmail()),
+						AuthorLogin:    commit.GetAuthor().GetLogin(),
+						CommitterName:  commit.GetCommit().GetCommitter().GetName(),
+						CommitterLogin: commit.GetCommitter().GetLogin(),
+
+						Verified:           commit.GetCommit().GetVerification().GetVerified(),
+						VerificationReason: commit.GetCommit().GetVerification().GetReason(),
+						IsMerge:            isMerge,
+					}
+					if opts.WithCoAuthors {
+						c.CoAuthors = parseCoAuthors(c.Message)
+					}
+					if opts.WithPatch {
+						c.Files, err = fetchCommitFiles(ctx, client, progress, *repo.Owner.Login, *repo.Name, *commit.SHA)
+						if err != nil {
+							return export, err
+						}
+					}
+					export.Commits = append(export.Commits, c)
+				}
+
+				if !opts.morePages(resp, page, "commits of "+repo.GetFullName()) {
+					break
+				}
+				opt.Page = resp.NextPage
+			}
+		case "pull_requests":
+
+			// Fetch pull requests
+			prOpt := &github.PullRequestListOptions{ListOptions: github.ListOptions{PerPage: opts.perPage()}}
+			if opts.MergedOnly {
+				// Merged pull requests are closed, and the most recently
+				// updated ones the most recently merged.
+				prOpt.State, prOpt.Sort, prOpt.Direction = "closed", "updated", "desc"
+			}
+		pullRequests:
+			for page := 1; ; page++ {
+				prs, resp, err := client.PullRequests.List(ctx, *repo.Owner.Login, *repo.Name, prOpt)
+				progress.observe(resp)
+				if err != nil {
+					return export, err
+				}
+				if page == 1 {
+					if err := followRename(ctx, client, opts, repo, resp); err != nil {
+						return export, err
+					}
+				}
+				opts.Stats.page(repo.GetFullName(), "pull requests", resp, page, len(prs))
+				for _, pr := range prs {
+					// The newest pull requests come first, by creation or,
+					// for merged ones, by update, which follows the merge.
+					sorted := pr.GetCreatedAt().Time
+					if opts.MergedOnly {
+						sorted = pr.GetUpdatedAt().Time
+					}
+					if !opts.Since.IsZero() && sorted.Before(opts.Since) {
+						break pullRequests
+					}
+					if !opts.wantPullRequest(pr.GetCreatedAt().Time, pr.GetMergedAt().Time) || !opts.wantDraft(pr.GetDraft()) {
+						continue
+					}
+					if opts.OnlyMine && !strings.EqualFold(pr.GetUser().GetLogin(), username) {
+						continue
+					}
+					// Unlike issues, pull requests cannot be listed by label.
+					if !opts.hasLabels(pr.Labels) {
+						continue
+					}
+					p := PullRequest{
+						Repo:     *repo.Name,
+						Number:   *pr.Number,
+						Title:    *pr.Title,
+						State:    *pr.State,
+						Draft:    pr.GetDraft(),
+						Author:   *pr.User.Login,
+						Date:     pr.CreatedAt.Time,
+						Body:     opts.body(pr.GetBody()),
+						Labels:   labelNames(pr.Labels),
+						MergedAt: pr.GetMergedAt().Time,
+
+						RequestedReviewers: requestedReviewers(pr),
+						RequestedTeams:     requestedTeams(pr),
+					}
+					if opts.WithCommits {
+						if p.Commits, err = fetchPullRequestCommits(ctx, client, progress, opts, *repo.Owner.Login, *repo.Name, p.Number); err != nil {
+							return export, err
+						}
+					}
+					export.PullRequests = append(export.PullRequests, p)
+				}
+
+				if !opts.morePages(resp, page, "pull requests of "+repo.GetFullName()) {
+					break
+				}
+				prOpt.Page = resp.NextPage
+			}
+		case "issues":
+			// Fetch issues
+			issueOpt := &github.IssueListByRepoOptions{ListOptions: github.ListOptions{PerPage: opts.perPage()}}
+			if opts.OnlyMine {
+				issueOpt.Creator = username
+			}
+			issueOpt.Labels = opts.Labels
+			// An issue created in the window was updated since as well.
+			issueOpt.Since = opts.Since
+			for page := 1; ; page++ {
+				issues, resp, err := client.Issues.ListByRepo(ctx, *repo.Owner.Login, *repo.Name, issueOpt)
+				progress.observe(resp)
+				if err != nil {
+					return export, err
+				}
+				if page == 1 {
+					if err := followRename(ctx, client, opts, repo, resp); err != nil {
+						return export, err
+					}
+				}
+				opts.Stats.page(repo.GetFullName(), "issues", resp, page, len(issues))
+				for _, issue := range issues {
+					if issue.PullRequestLinks == nil && opts.inWindow(issue.CreatedAt.Time) {
+						export.Issues = append(export.Issues, Issue{
+							Repo:   *repo.Name,
+							Number: *issue.Number,
+							Title:  *issue.Title,
+							State:  *issue.State,
+							Author: *issue.User.Login,
+							Date:   issue.CreatedAt.Time,
+							Body:   opts.body(issue.GetBody()),
+						})
+					}
+				}
+
+				if !opts.morePages(resp, page, "issues of "+repo.GetFullName()) {
+					break
+				}
+				issueOpt.Page = resp.NextPage
+			}
+
+		case "releases":
+			// Fetch releases
+			releaseOpt := &github.ListOptions{PerPage: opts.perPage()}
+			for page := 1; ; page++ {
+				releases, resp, err := client.Repositories.ListReleases(ctx, *repo.Owner.Login, *repo.Name, releaseOpt)
+				progress.observe(resp)
+				if err != nil {
+					return export, err
+				}
+				if page == 1 {
+					if err := followRename(ctx, client, opts, repo, resp); err != nil {
+						return export, err
+					}
+				}
+				opts.Stats.page(repo.GetFullName(), "releases", resp, page, len(releases))
+				for _, release := range releases {
+					if !opts.inWindow(release.GetCreatedAt().Time) || !opts.wantRelease(release) {
+						continue
+					}
+					// Releases created without a title have a null name.
+					export.Releases = append(export.Releases, Release{
+						Repo:       *repo.Name,
+						TagName:    release.GetTagName(),
+						Name:       release.GetName(),
+						Author:     release.GetAuthor().GetLogin(),
+						Date:       release.GetCreatedAt().Time,
+						Draft:      release.GetDraft(),
+						Prerelease: release.GetPrerelease(),
+						Assets:     releaseAssets(repo.GetFullName(), release),
+					})
+				}
+
+				if !opts.morePages(resp, page, "releases of "+repo.GetFullName()) {
+					break
+				}
+				releaseOpt.Page = resp.NextPage
+			}
+		case "checks":
+			// Fetch the check runs of the commits in the window
+			for page := 1; ; page++ {
+				commits, resp, err := client.Repositories.ListCommits(ctx, *repo.Owner.Login, *repo.Name, opt)
+				progress.observe(resp)
+				if err != nil {
+					return export, opts.commitsError(err, resp, repo)
+				}
+				if page == 1 {
+					if err := followRename(ctx, client, opts, repo, resp); err != nil {
+						return export, err
+					}
+				}
+				opts.Stats.page(repo.GetFullName(), "commits", resp, page, len(commits))
+				for _, commit := range commits {
+					runs, err := fetchCheckRuns(ctx, client, progress, opts, *repo.Owner.Login, *repo.Name, commit.GetSHA())
+					if err != nil {
+						return export, err
+					}
+					export.CheckRuns = append(export.CheckRuns, runs...)
+				}
+
+				if !opts.morePages(resp, page, "commits of "+repo.GetFullName()) {
+					break
+				}
+				opt.Page = resp.NextPage
+			}
+		case "deployments":
+			deployments, err := fetchDeployments(ctx, client, progress, opts, repo)
+			if err != nil {
+				return export, err
+			}
+			export.Deployments = append(export.Deployments, deployments...)
+		case "discussions":
+			discussions, err := fetchDiscussions(ctx, client, progress, opts, repo)
+			if err != nil {
+				return export, err
+			}
+			export.Discussions = append(export.Discussions, discussions...)
+		case "collaborators":
+			collaborators, err := fetchCollaborators(ctx, client, progress, opts, repo)
+			if err != nil {
+				return export, err
+			}
+			export.Collaborators = append(export.Collaborators, collaborators...)
+		case "stargazers":
+			stargazers, err := fetchStargazers(ctx, client, progress, opts, repo)
+			if err != nil {
+				return export, err
+			}
+			export.Stargazers = append(export.Stargazers, stargazers...)
+		default:
+			return export, fmt.Errorf("unsupported kind: %s", opts.Kind)
+		}
+		if err := opts.flush(&export); err != nil {
+			return export, err
+		}
+		progress.repoDone(repo.GetFullName())
+	}
+	return export, nil
+}
+
+// Repositories returns the repositories walked by an export with opts: those
+// in opts.Repos, or else those of the user or App installation, narrowed down
+// by the repository filters.
+func Repositories(ctx context.Context, client *github.Client, opts Options) ([]*github.Repository, error) {
+	var err error
+	if opts.Repos, err = normalizeRepos(ctx, client, opts.Repos); err != nil {
+		return nil, err
+	}
+	repos, err := listRepos(ctx, client, opts)
+	if err != nil {
+		return nil, err
+	}
+	return filterRepos(repos, opts), nil
+}
+
+// Login returns the login of the authenticated user.
+func Login(ctx context.Context, client *github.Client) (string, error) {
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return user.GetLogin(), nil
+}
+
+func newMeta(login string, opts Options) Meta {
+	return Meta{
+		SchemaVersion: SchemaVersion,
+		Login:         login,
+		GeneratedAt:   time.Now().UTC(),
+		Kind:          opts.Kind,
+		Mode:          opts.Mode,
+		Members:       opts.Members,
+	}
+}
+
+// listRepos lists the repositories given in opts.Repos, or else those owned by
+// the authenticated user or those of the App installation, going through the
+// repository cache when one is configured.
+func listRepos(ctx context.Context, client *github.Client, opts Options) ([]*github.Repository, error) {
+	if len(opts.Repos) > 0 {
+		return getRepos(ctx, client, opts)
+	}
+
+	if opts.RepoCache != "" && !opts.RefreshRepoCache {
+		if repos, ok := loadRepoCache(opts.RepoCache, opts.RepoCacheTTL); ok {
+			return repos, nil
+		}
+	}
+
+	var repos []*github.Repository
+	// List the installation's or the user's repositories
+	installationOpt := &github.ListOptions{PerPage: opts.perPage()}
+	userOpt := &github.RepositoryListByAuthenticatedUserOptions{
+		ListOptions: github.ListOptions{PerPage: opts.perPage()},
+		Affiliation: "owner",
+	}
+	complete := true
+	for page := 1; ; page++ {
+		var listed []*github.Repository
+		var resp *github.Response
+		var err error
+		if opts.InstallationRepos {
+			var installation *github.ListRepositories
+			installation, resp, err = client.Apps.ListRepos(ctx, installationOpt)
+			if installation != nil {
+				listed = installation.Repositories
+			}
+		} else {
+			listed, resp, err = client.Repositories.ListByAuthenticatedUser(ctx, userOpt)
+		}
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, listed...)
+
+		if !opts.morePages(resp, page, "repositories") {
+			complete = resp.NextPage == 0
+			break
+		}
+		installationOpt.Page = resp.NextPage
+		userOpt.Page = resp.NextPage
+	}
+
+	// A list cut short by MaxPages is not cached, later runs would take it
+	// for the whole list.
+	if opts.RepoCache != "" && complete {
+		if err := saveRepoCache(opts.RepoCache, repos); err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+// getRepos looks up the repositories in opts.Repos. Github redirects requests
+// for a renamed or transferred repository to its new location, which the
+// returned repository then describes. Repositories that are not found are
+// skipped unless opts.FailFast is set, as long as one of them is left.
+func getRepos(ctx context.Context, client *github.Client, opts Options) ([]*github.Repository, error) {
+	repos := make([]*github.Repository, 0, len(opts.Repos))
+	for _, fullName := range opts.Repos {
+		owner, name, err := parseRepo(fullName)
+		if err != nil {
+			return nil, err
+		}
+		repo, resp, err := client.Repositories.Get(ctx, owner, name)
+		// Github answers 404 rather than 403 for private repositories the
+		// token cannot see, so a typo and a missing scope look the same.
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			err := fmt.Errorf("repository %s not found or not accessible with this token", fullName)
+			if opts.FailFast {
+				return nil, err
+			}
+			opts.warn("%v, skipping it", err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: %w", fullName, err)
+		}
+		if !strings.EqualFold(repo.GetFullName(), fullName) {
+			opts.warn("repository %s was renamed to %s", fullName, repo.GetFullName())
+		}
+		repos = append(repos, repo)
+	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("none of the repositories %s were found", strings.Join(opts.Repos, ", "))
+	}
+	return repos, nil
+}
+
+// snapshotRepos records the default branch of repos, and its HEAD commit
+// with opts.WithHeadSHA.
+func snapshotRepos(ctx context.Context, client *github.Client, repos []*github.Repository, opts Options) ([]Repository, error) {
+	snapshot := make([]Repository, 0, len(repos))
+	for _, repo := range repos {
+		r := Repository{
+			FullName:      repo.GetFullName(),
+			DefaultBranch: repo.GetDefaultBranch(),
+		}
+		// Empty repositories have no default branch to look up.
+		if opts.WithHeadSHA && r.DefaultBranch != "" {
+			ref, _, err := client.Git.GetRef(ctx, repo.GetOwner().GetLogin(), repo.GetName(), "heads/"+r.DefaultBranch)
+			if err != nil {
+				return nil, fmt.Errorf("looking up HEAD of %s: %w", r.FullName, err)
+			}
+			r.HeadSHA = ref.GetObject().GetSHA()
+		}
+		snapshot = append(snapshot, r)
+	}
+	return snapshot, nil
+}
+
+// followRename checks whether the request behind resp was redirected because
+// repo has been renamed or transferred since it was listed, as happens with a
+// stale repository cache, and if so updates repo to its new location.
+func followRename(ctx context.Context, client *github.Client, opts Options, repo *github.Repository, resp *github.Response) error {
+	// Github redirects to the repository by ID rather than by name.
+	if resp == nil || resp.Request == nil || !strings.Contains(resp.Request.URL.Path, "/repositories/") {
+		return nil
+	}
+	moved, _, err := client.Repositories.GetByID(ctx, repo.GetID())
+	if err != nil {
+		return err
+	}
+	if moved.GetFullName() != repo.GetFullName() {
+		opts.warn("repository %s was renamed to %s", repo.GetFullName(), moved.GetFullName())
+		*repo = *moved
+	}
+	return nil
+}
+
+// commitsError explains a failed commit listing of repo: Github answers 404
+// for a Branch the repository does not have.
+func (opts Options) commitsError(err error, resp *github.Response, repo *github.Repository) error {
+	if opts.Branch != "" && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("branch %s does not exist in %s", opts.Branch, repo.GetFullName())
+	}
+	return err
+}
+
+// morePages reports whether a listing continues after its page-th page. It
+// stops early, with a warning, once opts.MaxPages pages have been fetched,
+// and logs the next page to opts.Logger with the total from the Link header.
+func (opts Options) morePages(resp *github.Response, page int, what string) bool {
+	if resp.NextPage == 0 || !opts.belowMaxPages(page, what) {
+		return false
+	}
+	if opts.Logger != nil {
+		if total := lastPage(resp, page); total != 0 {
+			opts.Logger.Printf("listing %s, page %d of %d", what, page+1, total)
+		} else {
+			opts.Logger.Printf("listing %s, page %d", what, page+1)
+		}
+	}
+	return true
+}
+
+// perPage returns the page size of listings, opts.PerPage or else the
+// maximum of 100.
+func (opts Options) perPage() int {
+	if opts.PerPage == 0 {
+		return 100
+	}
+	return opts.PerPage
+}
+
+// belowMaxPages reports whether a listing may fetch the page after its
+// page-th page under opts.MaxPages, warning when it may not.
+func (opts Options) belowMaxPages(page int, what string) bool {
+	if opts.MaxPages > 0 && page >= opts.MaxPages {
+		opts.warn("stopped listing %s after %d pages, the export is incomplete", what, page)
+		return false
+	}
+	return true
+}
+
+// warn logs a warning to opts.Warnings.
+func (opts Options) warn(format string, args ...any) {
+	if opts.Warnings != nil {
+		opts.Warnings.Printf(format, args...)
+	}
+}
+
+// filterRepos returns the repositories matching the repository filters in
+// opts.
+func filterRepos(repos []*github.Repository, opts Options) []*github.Repository {
+	filtered := repos[:0]
+	for _, repo := range repos {
+		if repo.GetStargazersCount() < opts.MinStars {
+			continue
+		}
+		if !opts.PushedSince.IsZero() && repo.GetPushedAt().Before(opts.PushedSince) {
+			continue
+		}
+		if opts.ExcludeForks && repo.GetFork() {
+			continue
+		}
+		if opts.ExcludeArchived && repo.GetArchived() {
+			continue
+		}
+		filtered = append(filtered, repo)
+	}
+	return filtered
+}
+
+// fetchWatched returns every repository watched by login.
+func fetchWatched(ctx context.Context, client *github.Client, login string, opts Options) ([]Watch, error) {
+	var watched []Watch
+
+	opt := &github.ListOptions{PerPage: opts.perPage()}
+	for page := 1; ; page++ {
+		repos, resp, err := client.Activity.ListWatched(ctx, login, opt)
+		if err != nil {
+			return nil, err
+		}
+		opts.Stats.page("", "watched", resp, page, len(repos))
+		for _, repo := range repos {
+			watched = append(watched, Watch{
+				Repo:   repo.GetFullName(),
+				Author: login,
+				Action: "watching",
+			})
+		}
+
+		if !opts.morePages(resp, page, "watched repositories") {
+			return watched, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// fetchCommitFiles returns the files changed by a single commit, including
+// their patches.
+func fetchCommitFiles(ctx context.Context, client *github.Client, progress *progress, owner, repo, sha string) ([]CommitFile, error) {
+	commit, resp, err := client.Repositories.GetCommit(ctx, owner, repo, sha, nil)
+	progress.observe(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]CommitFile, 0, len(commit.Files))
+	for _, file := range commit.Files {
+		files = append(files, CommitFile{
+			Filename:  file.GetFilename(),
+			Status:    file.GetStatus(),
+			Additions: file.GetAdditions(),
+			Deletions: file.GetDeletions(),
+			Patch:     file.GetPatch(),
+		})
+	}
+	return files, nil
+}
+
+// fetchPullRequestCommits returns the commits of pull request number of
+// owner/repo.
+func fetchPullRequestCommits(ctx context.Context, client *github.Client, progress *progress, opts Options, owner, repo string, number int) ([]PullRequestCommit, error) {
+	var commits []PullRequestCommit
+
+	opt := &github.ListOptions{PerPage: opts.perPage()}
+	for page := 1; ; page++ {
+		listed, resp, err := client.PullRequests.ListCommits(ctx, owner, repo, number, opt)
+		progress.observe(resp)
+		if err != nil {
+			return nil, err
+		}
+		opts.Stats.page(owner+"/"+repo, "pull request commits", resp, page, len(listed))
+		for _, commit := range listed {
+			commits = append(commits, PullRequestCommit{
+				SHA:     commit.GetSHA(),
+				Message: commit.GetCommit().GetMessage(),
+			})
+		}
+
+		if !opts.morePages(resp, page, fmt.Sprintf("commits of %s/%s#%d", owner, repo, number)) {
+			return commits, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// addPullRequestCommits lists the commits of pr, whose Repo is given as
+// owner/name, with opts.WithCommits.
+func (opts Options) addPullRequestCommits(ctx context.Context, client *github.Client, pr *PullRequest) error {
+	if !opts.WithCommits {
+		return nil
+	}
+	owner, name, err := parseRepo(pr.Repo)
+	if err != nil {
+		return err
+	}
+	pr.Commits, err = fetchPullRequestCommits(ctx, client, nil, opts, owner, name, pr.Number)
+	return err
+}
+
+// latestRelease returns the tag and publication time of the latest release
+// of repo, or an empty tag and the zero time if it has not been released yet.
+func latestRelease(ctx context.Context, client *github.Client, progress *progress, repo *github.Repository) (string, time.Time, error) {
+	release, resp, err := client.Repositories.GetLatestRelease(ctx, *repo.Owner.Login, *repo.Name)
+	progress.observe(resp)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return release.GetTagName(), release.GetPublishedAt().Time, nil
+}
+
+// fetchCheckRuns returns every check run on a commit.
+func fetchCheckRuns(ctx context.Context, client *github.Client, progress *progress, opts Options, owner, repo, sha string) ([]CheckRun, error) {
+	var runs []CheckRun
+
+	opt := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: opts.perPage()}}
+	for page := 1; ; page++ {
+		result, resp, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, opt)
+		progress.observe(resp)
+		if err != nil {
+			return nil, err
+		}
+		opts.Stats.page(owner+"/"+repo, "check runs", resp, page, len(result.CheckRuns))
+		for _, run := range result.CheckRuns {
+			runs = append(runs, CheckRun{
+				Repo:        repo,
+				ID:          run.GetID(),
+				SHA:         sha,
+				Name:        run.GetName(),
+				Status:      run.GetStatus(),
+				Conclusion:  run.GetConclusion(),
+				CompletedAt: run.GetCompletedAt().Time,
+			})
+		}
+
+		if !opts.morePages(resp, page, "check runs of "+repo+"@"+sha) {
+			return runs, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// fetchDeployments returns the deployments of repo created in the export
+// window. Deployments are listed newest first, so paging stops at the first
+// one created before opts.Since.
+func fetchDeployments(ctx context.Context, client *github.Client, progress *progress, opts Options, repo *github.Repository) ([]Deployment, error) {
+	var deployments []Deployment
+
+	opt := &github.DeploymentsListOptions{ListOptions: github.ListOptions{PerPage: opts.perPage()}}
+	for page := 1; ; page++ {
+		result, resp, err := client.Repositories.ListDeployments(ctx, *repo.Owner.Login, *repo.Name, opt)
+		progress.observe(resp)
+		if err != nil {
+			return nil, err
+		}
+		if page == 1 {
+			if err := followRename(ctx, client, opts, repo, resp); err != nil {
+				return nil, err
+			}
+		}
+		opts.Stats.page(repo.GetFullName(), "deployments", resp, page, len(result))
+		for _, deployment := range result {
+			created := deployment.GetCreatedAt().Time
+			if !opts.Since.IsZero() && created.Before(opts.Since) {
+				return deployments, nil
+			}
+			if !opts.inWindow(created) {
+				continue
+			}
+			deployments = append(deployments, Deployment{
+				Repo:        *repo.Name,
+				ID:          deployment.GetID(),
+				Environment: deployment.GetEnvironment(),
+				Ref:         deployment.GetRef(),
+				SHA:         deployment.GetSHA(),
+				Creator:     deployment.GetCreator().GetLogin(),
+				Date:        created,
+			})
+		}
+
+		if !opts.morePages(resp, page, "deployments of "+repo.GetFullName()) {
+			return deployments, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// fetchCollaborators returns the collaborators of repo with their role.
+// Listing them needs push access to the repository; without it Github
+// answers 403, which skips the repository with a warning.
+func fetchCollaborators(ctx context.Context, client *github.Client, progress *progress, opts Options, repo *github.Repository) ([]Collaborator, error) {
+	var collaborators []Collaborator
+
+	opt := &github.ListCollaboratorsOptions{ListOptions: github.ListOptions{PerPage: opts.perPage()}}
+	for page := 1; ; page++ {
+		users, resp, err := client.Repositories.ListCollaborators(ctx, *repo.Owner.Login, *repo.Name, opt)
+		progress.observe(resp)
+		if resp != nil && resp.StatusCode == http.StatusForbidden && !isRateLimited(resp.Response) {
+			opts.warn("skipping the collaborators of %s, the token is not allowed to list them", repo.GetFullName())
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if page == 1 {
+			if err := followRename(ctx, client, opts, repo, resp); err != nil {
+				return nil, err
+			}
+		}
+		opts.Stats.page(repo.GetFullName(), "collaborators", resp, page, len(users))
+		for _, user := range users {
+			collaborators = append(collaborators, Collaborator{
+				Repo:       *repo.Name,
+				Login:      user.GetLogin(),
+				Permission: permission(user),
+			})
+		}
+
+		if !opts.morePages(resp, page, "collaborators of "+repo.GetFullName()) {
+			return collaborators, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// stargazerWarning is the number of stargazers from which listing those of a
+// repository is warned about.
+const stargazerWarning = 10000
+
+// fetchStargazers returns the users who starred repo within the date range,
+// which Github lists oldest first, one page of up to 100 per request.
+func fetchStargazers(ctx context.Context, client *github.Client, progress *progress, opts Options, repo *github.Repository) ([]Stargazer, error) {
+	if count := repo.GetStargazersCount(); count >= stargazerWarning {
+		opts.warn("%s has %d stargazers, listing them takes about %d requests", repo.GetFullName(), count, count/opts.perPage()+1)
+	}
+
+	var stargazers []Stargazer
+	opt := &github.ListOptions{PerPage: opts.perPage()}
+	for page := 1; ; page++ {
+		users, resp, err := client.Activity.ListStargazers(ctx, *repo.Owner.Login, *repo.Name, opt)
+		progress.observe(resp)
+		if err != nil {
+			return nil, err
+		}
+		if page == 1 {
+			if err := followRename(ctx, client, opts, repo, resp); err != nil {
+				return nil, err
+			}
+		}
+		opts.Stats.page(repo.GetFullName(), "stargazers", resp, page, len(users))
+		for _, user := range users {
+			if !opts.inWindow(user.GetStarredAt().Time) {
+				continue
+			}
+			stargazers = append(stargazers, Stargazer{
+				Repo:      *repo.Name,
+				Login:     user.GetUser().GetLogin(),
+				StarredAt: user.GetStarredAt().Time,
+			})
+		}
+
+		if !opts.morePages(resp, page, "stargazers of "+repo.GetFullName()) {
+			return stargazers, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// permission returns the role of a collaborator, or else the highest of
+// their permissions.
+func permission(user *github.User) string {
+	if role := user.GetRoleName(); role != "" {
+		return role
+	}
+	for _, p := range []string{"admin", "maintain", "push", "triage", "pull"} {
+		if user.Permissions[p] {
+			return p
+		}
+	}
+	return ""
+}
+
+func fetchGitHubEvents(ctx context.Context, client *github.Client, login string, opts Options) (Export, error) {
+	export := Export{}
+	export.Meta = newMeta(login, opts)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for page := range fetchEventPages(ctx, client, login, opts) {
+		if page.err != nil {
+			return export, page.err
+		}
+
+		for _, event := range page.events {
+			if event.GetActor().GetLogin() != login || !opts.wantRepo(event.GetRepo().GetName()) {
+				continue
+			}
+			// Events are returned newest first, so everything after an
+			// event older than Since is out of the window as well.
+			if !opts.Since.IsZero() && event.GetCreatedAt().Before(opts.Since) {
+				return export, nil
+			}
+			if !opts.inWindow(event.GetCreatedAt().Time) {
+				continue
+			}
+
+			payload, err := event.ParsePayload()
+			if err != nil {
+				continue
+			}
+
+			switch event.GetType() {
+			case "PushEvent":
+				if p, ok := payload.(*github.PushEvent); ok {
+					for _, commit := range p.Commits {
+						c := Commit{
+							Repo:    event.GetRepo().GetName(),
+							SHA:     commit.GetSHA(),
+							Message: *commit.Message,
+							Author:  commit.GetAuthor().GetName(),
+							Date:    event.GetCreatedAt().Time,
+
+							AuthorEmail: opts.authorEmail(commit.GetAuthor().GetEmail()),
+						}
+						if opts.WithCoAuthors {
+							c.CoAuthors = parseCoAuthors(c.Message)
+						}
+						export.Commits = append(export.Commits, c)
+					}
+				}
+			case "PullRequestEvent":
+				if p, ok := payload.(*github.PullRequestEvent); ok && opts.wantDraft(p.GetPullRequest().GetDraft()) && (!opts.MergedOnly || p.GetPullRequest().GetMerged()) && opts.hasLabels(p.GetPullRequest().Labels) {
+					export.PullRequests = append(export.PullRequests, PullRequest{
+						Repo:   event.GetRepo().GetName(),
+						Number: p.GetPullRequest().GetNumber(),
+						Title:  p.GetPullRequest().GetTitle(),
+						Draft:  p.GetPullRequest().GetDraft(),
+						Author: login,
+						Action: p.GetAction(),
+						Date:   event.GetCreatedAt().Time,
+						Body:   opts.body(p.GetPullRequest().GetBody()),
+
+						Labels:             labelNames(p.GetPullRequest().Labels),
+						MergedAt:           p.GetPullRequest().GetMergedAt().Time,
+						RequestedReviewers: requestedReviewers(p.GetPullRequest()),
+						RequestedTeams:     requestedTeams(p.GetPullRequest()),
+					})
+					if err := opts.addPullRequestCommits(ctx, client, &export.PullRequests[len(export.PullRequests)-1]); err != nil {
+						return export, err
+					}
+				}
+			case "IssuesEvent":
+				if p, ok := payload.(*github.IssuesEvent); ok && opts.hasLabels(p.GetIssue().Labels) {
+					export.Issues = append(export.Issues, Issue{
+						Repo:   event.GetRepo().GetName(),
+						Number: p.GetIssue().GetNumber(),
+						Title:  p.GetIssue().GetTitle(),
+						Author: login,
+						Action: p.GetAction(),
+						Date:   event.GetCreatedAt().Time,
+						Body:   opts.body(p.GetIssue().GetBody()),
+					})
+				}
+			case "ReleaseEvent":
+				if p, ok := payload.(*github.ReleaseEvent); ok && opts.wantRelease(p.GetRelease()) {
+					export.Releases = append(export.Releases, Release{
+						Repo:       event.GetRepo().GetName(),
+						TagName:    p.GetRelease().GetTagName(),
+						Name:       p.GetRelease().GetName(),
+						Author:     login,
+						Action:     p.GetAction(),
+						Date:       event.GetCreatedAt().Time,
+						Draft:      p.GetRelease().GetDraft(),
+						Prerelease: p.GetRelease().GetPrerelease(),
+						Assets:     releaseAssets(event.GetRepo().GetName(), p.GetRelease()),
+					})
+				}
+			case "WatchEvent":
+				if p, ok := payload.(*github.WatchEvent); ok {
+					export.Watch = append(export.Watch, Watch{
+						Repo:   event.GetRepo().GetName(),
+						Author: login,
+						Action: p.GetAction(),
+						Date:   event.GetCreatedAt().Time,
+					})
+				}
+			}
+
+		}
+		if err := opts.flush(&export); err != nil {
+			return export, err
+		}
+	}
+
+	return export, nil
+}
+
+type eventPage struct {
+	events []*github.Event
+	err    error
+}
+
+// fetchEventPages pages through the events performed by login in the
+// background, fetching the next page while the caller processes the current
+// one. Pages are delivered in order; an error ends the stream. Cancel ctx to
+// stop early.
+func fetchEventPages(ctx context.Context, client *github.Client, login string, opts Options) <-chan eventPage {
+	pages := make(chan eventPage, 1)
+	go func() {
+		defer close(pages)
+
+		opt := &github.ListOptions{PerPage: opts.perPage()}
+		for n := 1; ; n++ {
+			events, resp, err := client.Activity.ListEventsPerformedByUser(ctx, login, false, opt)
+			if err == nil {
+				opts.Stats.page("", "events", resp, n, len(events))
+			}
+			select {
+			case pages <- eventPage{events: events, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil || !opts.morePages(resp, n, "events") {
+				return
+			}
+			opt.Page = resp.NextPage
+		}
+	}()
+	return pages
+}
+
+// requestedReviewers returns the logins of the users whose review of pr is
+// pending.
+func requestedReviewers(pr *github.PullRequest) []string {
+	var logins []string
+	for _, user := range pr.RequestedReviewers {
+		if login := user.GetLogin(); login != "" {
+			logins = append(logins, login)
+		}
+	}
+	return logins
+}
+
+// labelNames returns the names of labels.
+func labelNames(labels []*github.Label) []string {
+	var names []string
+	for _, label := range labels {
+		names = append(names, label.GetName())
+	}
+	return names
+}
+
+// requestedTeams returns the slugs of the teams whose review of pr is
+// pending.
+func requestedTeams(pr *github.PullRequest) []string {
+	var slugs []string
+	for _, team := range pr.RequestedTeams {
+		if slug := team.GetSlug(); slug != "" {
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs
+}
@@ -0,0 +1,345 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// newTestClient returns a client sending its requests to handler.
+func newTestClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client
+}
+
+// pageLinks sets the Link header of the page-th of last pages of r.
+func pageLinks(w http.ResponseWriter, r *http.Request, page, last int) {
+	link := func(n int, rel string) string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(n))
+		u.RawQuery = q.Encode()
+		return fmt.Sprintf(`<http://%s%s>; rel="%s"`, r.Host, u.RequestURI(), rel)
+	}
+	if page < last {
+		w.Header().Set("Link", link(page+1, "next")+", "+link(last, "last"))
+	}
+}
+
+// requestPage returns the page requested by r, 1 when unset.
+func requestPage(r *http.Request) int {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil {
+		return 1
+	}
+	return page
+}
+
+func TestFetchEventsStopsAtSince(t *testing.T) {
+	const pages, perPage = 6, 2
+	newest := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	requested := map[int]int{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/user" {
+			json.NewEncoder(w).Encode(map[string]any{"login": "octo"})
+			return
+		}
+		page := requestPage(r)
+		mu.Lock()
+		requested[page]++
+		mu.Unlock()
+
+		// Event i of the stream is i days older than newest.
+		var events []map[string]any
+		for i := (page - 1) * perPage; i < page*perPage; i++ {
+			events = append(events, map[string]any{
+				"type":       "IssuesEvent",
+				"actor":      map[string]any{"login": "octo"},
+				"repo":       map[string]any{"name": "octo/repo"},
+				"created_at": newest.AddDate(0, 0, -i),
+				"payload":    map[string]any{"action": "opened", "issue": map[string]any{"number": i}},
+			})
+		}
+		pageLinks(w, r, page, pages)
+		json.NewEncoder(w).Encode(events)
+	}))
+
+	opts := Options{
+		Kind:  "issues",
+		Mode:  "events",
+		Since: newest.AddDate(0, 0, -3),
+		Until: newest.AddDate(0, 0, -1),
+	}
+	export, err := Fetch(context.Background(), client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var numbers []int
+	for _, issue := range export.Issues {
+		numbers = append(numbers, issue.Number)
+	}
+	if fmt.Sprint(numbers) != "[1 2 3]" {
+		t.Errorf("exported issues %v, want [1 2 3]", numbers)
+	}
+
+	// The event before Since is on page 3. The page after it may already
+	// be fetched ahead, but not any further one.
+	mu.Lock()
+	defer mu.Unlock()
+	for page := 1; page <= 3; page++ {
+		if requested[page] != 1 {
+			t.Errorf("page %d requested %d times, want once", page, requested[page])
+		}
+	}
+	for page := 5; page <= pages; page++ {
+		if requested[page] != 0 {
+			t.Errorf("page %d requested after the window ended", page)
+		}
+	}
+}
+
+// listingItems are n items of the listings of a kind, by kind.
+var listingItems = map[string]func(i int) map[string]any{
+	"commits": func(i int) map[string]any {
+		return map[string]any{
+			"sha":    fmt.Sprintf("%040x", i+1),
+			"commit": map[string]any{"message": "change", "author": map[string]any{"name": "Octo", "date": testDate}},
+		}
+	},
+	"pull_requests": func(i int) map[string]any {
+		return map[string]any{"number": i + 1, "title": "change", "state": "open", "user": map[string]any{"login": "octo"}, "created_at": testDate}
+	},
+	"issues": func(i int) map[string]any {
+		return map[string]any{"number": i + 1, "title": "bug", "state": "open", "user": map[string]any{"login": "octo"}, "created_at": testDate}
+	},
+	"releases": func(i int) map[string]any {
+		return map[string]any{"tag_name": fmt.Sprintf("v%d", i+1), "name": "release", "author": map[string]any{"login": "octo"}, "created_at": testDate}
+	},
+}
+
+var testDate = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// newRepoServer returns a client for a server holding the repository
+// octo/repo with n items in each of its listings, and the number of requests
+// per path it served.
+func newRepoServer(t *testing.T, n int) (*github.Client, map[string]int) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	paths := map[string]string{
+		"/repos/octo/repo/commits":  "commits",
+		"/repos/octo/repo/pulls":    "pull_requests",
+		"/repos/octo/repo/issues":   "issues",
+		"/repos/octo/repo/releases": "releases",
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		if r.URL.Path == "/repos/octo/repo" {
+			json.NewEncoder(w).Encode(map[string]any{"id": 1, "name": "repo", "full_name": "octo/repo", "owner": map[string]any{"login": "octo"}})
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/check-runs") {
+			json.NewEncoder(w).Encode(map[string]any{"total_count": 0, "check_runs": []any{}})
+			return
+		}
+		kind, ok := paths[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		page := requestPage(r)
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		items := []map[string]any{}
+		for i := (page - 1) * perPage; i < min(page*perPage, n); i++ {
+			items = append(items, listingItems[kind](i))
+		}
+		pageLinks(w, r, page, (n+perPage-1)/perPage)
+		json.NewEncoder(w).Encode(items)
+	}))
+	return client, requests
+}
+
+func TestFetchGitHubDataPaginates(t *testing.T) {
+	for _, kind := range []string{"commits", "pull_requests", "issues", "releases", "checks"} {
+		t.Run(kind, func(t *testing.T) {
+			client, requests := newRepoServer(t, 25)
+			opts := Options{Kind: kind, Repos: []string{"octo/repo"}, PerPage: 10}
+			export, err := fetch(context.Background(), client, "octo", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := Count(export, kind)
+			if kind == "checks" {
+				// The commits have no check runs, count those listed.
+				got = 0
+				for path, n := range requests {
+					if strings.HasSuffix(path, "/check-runs") {
+						got += n
+					}
+				}
+			}
+			if got != 25 {
+				t.Errorf("got %d %s, want all 25", got, kind)
+			}
+		})
+	}
+}
+
+func TestFetchGitHubDataMaxPages(t *testing.T) {
+	for _, kind := range []string{"commits", "pull_requests", "issues", "releases"} {
+		t.Run(kind, func(t *testing.T) {
+			client, _ := newRepoServer(t, 25)
+			var warnings strings.Builder
+			opts := Options{
+				Kind:     kind,
+				Repos:    []string{"octo/repo"},
+				PerPage:  10,
+				MaxPages: 2,
+				Warnings: log.New(&warnings, "", 0),
+			}
+			export, err := fetch(context.Background(), client, "octo", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Count(export, kind); got != 20 {
+				t.Errorf("exported %d %s, want the 20 of the first 2 pages", got, kind)
+			}
+			if !strings.Contains(warnings.String(), "after 2 pages, the export is incomplete") {
+				t.Errorf("no truncation warning, got %q", warnings.String())
+			}
+		})
+	}
+}
+
+func TestFetchGitHubDataReleaseWithoutName(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo":
+			json.NewEncoder(w).Encode(map[string]any{"id": 1, "name": "repo", "full_name": "octo/repo", "owner": map[string]any{"login": "octo"}})
+		case "/repos/octo/repo/releases":
+			fmt.Fprintf(w, `[{"tag_name": "v1.0.0", "name": null, "author": null, "created_at": %q}]`, testDate.Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	opts := Options{Kind: "releases", Repos: []string{"octo/repo"}}
+	export, err := fetch(context.Background(), client, "octo", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Releases) != 1 || export.Releases[0].TagName != "v1.0.0" || export.Releases[0].Name != "" {
+		t.Errorf("exported releases %+v, want v1.0.0 without a name", export.Releases)
+	}
+}
+
+func TestFetchWatchedOfLogin(t *testing.T) {
+	var paths []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		json.NewEncoder(w).Encode([]map[string]any{{"full_name": "octo/repo"}})
+	}))
+
+	watched, err := fetchWatched(context.Background(), client, "alice", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(paths) != "[/users/alice/subscriptions]" {
+		t.Errorf("requested %v, want the subscriptions of alice", paths)
+	}
+	if len(watched) != 1 || watched[0].Repo != "octo/repo" || watched[0].Author != "alice" {
+		t.Errorf("watched %+v, want octo/repo by alice", watched)
+	}
+}
+
+func TestTeamMembersMaxPages(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := requestPage(r)
+		pageLinks(w, r, page, 5)
+		json.NewEncoder(w).Encode([]map[string]any{{"login": fmt.Sprintf("member%d", page)}})
+	}))
+
+	var warnings strings.Builder
+	opts := Options{PerPage: 1, MaxPages: 2, Warnings: log.New(&warnings, "", 0)}
+	members, err := TeamMembers(context.Background(), client, "octo", "core", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(members) != "[member1 member2]" {
+		t.Errorf("listed members %v, want those of the first 2 pages", members)
+	}
+	if !strings.Contains(warnings.String(), "stopped listing members of octo/core after 2 pages") {
+		t.Errorf("no truncation warning, got %q", warnings.String())
+	}
+}
+
+// newReposServer returns a client for a server listing n repositories of the
+// authenticated user.
+func newReposServer(t *testing.T, n int) *github.Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/repos" {
+			http.NotFound(w, r)
+			return
+		}
+		page := requestPage(r)
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		repos := []map[string]any{}
+		for i := (page - 1) * perPage; i < min(page*perPage, n); i++ {
+			repos = append(repos, map[string]any{"id": i + 1, "name": fmt.Sprintf("repo%d", i+1), "owner": map[string]any{"login": "octo"}})
+		}
+		pageLinks(w, r, page, (n+perPage-1)/perPage)
+		json.NewEncoder(w).Encode(repos)
+	}))
+}
+
+func TestListReposPaginates(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "repos.json")
+	repos, err := listRepos(context.Background(), newReposServer(t, 25), Options{PerPage: 10, RepoCache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 25 {
+		t.Errorf("listed %d repositories, want all 25", len(repos))
+	}
+	if cached, ok := loadRepoCache(cache, time.Hour); !ok || len(cached) != 25 {
+		t.Errorf("cached %d repositories, want all 25", len(cached))
+	}
+}
+
+func TestListReposMaxPagesNotCached(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "repos.json")
+	opts := Options{PerPage: 10, MaxPages: 2, RepoCache: cache}
+	repos, err := listRepos(context.Background(), newReposServer(t, 25), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 20 {
+		t.Errorf("listed %d repositories, want the 20 of the first 2 pages", len(repos))
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("cached the incomplete list of repositories")
+	}
+}
@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// renameColumns applies fields to the names of a header, failing when two
+// columns end up with the same name.
+func renameColumns(header []string, fields map[string]string) ([]string, error) {
+	if len(fields) == 0 || header == nil {
+		return header, nil
+	}
+	renamed := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		if to, ok := fields[name]; ok {
+			name = to
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("field map: more than one column is named %s", name)
+		}
+		seen[name] = true
+		renamed[i] = name
+	}
+	return renamed, nil
+}
+
+// renameKeys applies fields to the keys of the JSON object in data. It fails
+// when two keys end up with the same name.
+func renameKeys(data []byte, fields map[string]string) ([]byte, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+	seen := map[string]bool{}
+	return mapObject(data, func(key string, value json.RawMessage) (string, json.RawMessage, error) {
+		if to, ok := fields[key]; ok {
+			key = to
+		}
+		if seen[key] {
+			return "", nil, fmt.Errorf("field map: more than one field is named %s", key)
+		}
+		seen[key] = true
+		return key, value, nil
+	})
+}
+
+// recordTypes maps the keys of the record lists in the JSON encoding of an
+// Export to a record of their type.
+var recordTypes = map[string]record{
+	"commits":       Commit{},
+	"pull_requests": PullRequest{},
+	"issues":        Issue{},
+	"releases":      Release{},
+	"watch":         Watch{},
+	"check_runs":    CheckRun{},
+	"deployments":   Deployment{},
+	"timeline":      TimelineEvent{},
+	"discussions":   Discussion{},
+	"collaborators": Collaborator{},
+	"stargazers":    Stargazer{},
+}
+
+// renameRecords encodes export as JSON with fields applied to the keys of
+// every record, leaving out their empty fields with opts.OmitEmpty. The meta
+// object is left alone.
+func renameRecords(export Export, opts WriteOptions) ([]byte, error) {
+	data, err := json.Marshal(export)
+	if err != nil || (len(opts.Fields) == 0 && !opts.OmitEmpty) {
+		return data, err
+	}
+	return encodeLists(data, opts)
+}
+
+// encodeLists applies opts to the records of the lists in the JSON object in
+// data, keyed like the lists of an Export. The meta and stats members are left
+// alone.
+func encodeLists(data []byte, opts WriteOptions) ([]byte, error) {
+	return mapObject(data, func(key string, value json.RawMessage) (string, json.RawMessage, error) {
+		if key == "meta" || key == "stats" {
+			return key, value, nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return "", nil, err
+		}
+		for i, item := range items {
+			var err error
+			if items[i], err = encodeRecord(item, recordTypes[key], opts); err != nil {
+				return "", nil, err
+			}
+		}
+		value, err := json.Marshal(items)
+		return key, value, err
+	})
+}
+
+// encodeRecord applies opts to data, the JSON encoding of a record of the
+// type of r. r is nil for lists other than records, which are only renamed.
+func encodeRecord(data []byte, r record, opts WriteOptions) ([]byte, error) {
+	if opts.OmitEmpty && r != nil {
+		var err error
+		if data, err = omitEmpty(data, requiredNames(r)); err != nil {
+			return nil, err
+		}
+	}
+	return renameKeys(data, opts.Fields)
+}
+
+// emptyValues are the JSON encodings of the zero values of the record fields.
+var emptyValues = []string{`""`, `0`, `false`, `null`, `[]`, `{}`, `"0001-01-01T00:00:00Z"`}
+
+// omitEmpty drops the members of the JSON object in data whose value is
+// empty, except for the keep fields, which are always written.
+func omitEmpty(data []byte, keep []string) ([]byte, error) {
+	return mapObject(data, func(key string, value json.RawMessage) (string, json.RawMessage, error) {
+		if !slices.Contains(keep, key) && slices.Contains(emptyValues, string(value)) {
+			return key, nil, nil
+		}
+		return key, value, nil
+	})
+}
+
+// mapObject rewrites the members of the JSON object in data with fn, keeping
+// their order. Members for which fn returns a nil value are dropped.
+func mapObject(data []byte, fn func(key string, value json.RawMessage) (string, json.RawMessage, error)) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("field map: expected a JSON object")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		key, value, err := fn(token.(string), value)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// graphQL runs query with variables against the Github GraphQL API and
+// decodes its data into data. It goes through client, and so through the
+// same transports, as the REST calls.
+func graphQL(ctx context.Context, client *github.Client, query string, variables map[string]any, data any) (*github.Response, error) {
+	// The GraphQL endpoint is /graphql on github.com and /api/graphql on
+	// Enterprise, next to the /api/v3/ REST base URL, so it is resolved
+	// relative to the base URL's parent.
+	endpoint := "graphql"
+	if strings.HasSuffix(client.BaseURL.Path, "/v3/") {
+		endpoint = "../graphql"
+	}
+	req, err := client.NewRequest("POST", endpoint, map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data   any `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	result.Data = data
+	resp, err := client.Do(ctx, req, &result)
+	if err != nil {
+		return resp, err
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			messages[i] = e.Message
+		}
+		return resp, fmt.Errorf("graphql: %s", strings.Join(messages, "; "))
+	}
+	return resp, nil
+}
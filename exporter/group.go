@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// GroupByFields lists the fields records can be grouped by.
+var GroupByFields = []string{"repo", "author", "member", "month", "day"}
+
+// Group is the number of records sharing the same key.
+type Group struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// GroupBy counts the records of the given kind in export per value of field,
+// one of GroupByFields. Groups are sorted by key, which orders months and days
+// chronologically.
+func GroupBy(export Export, kind, field string) ([]Group, error) {
+	var key func(record) string
+	switch field {
+	case "repo":
+		key = record.repo
+	case "author":
+		key = record.author
+	case "member":
+		key = record.member
+	case "month":
+		key = func(r record) string { return r.date().Format("2006-01") }
+	case "day":
+		key = func(r record) string { return r.date().Format("2006-01-02") }
+	default:
+		return nil, fmt.Errorf("unsupported group by field: %s", field)
+	}
+
+	counts := map[string]int{}
+	for _, r := range records(export, kind) {
+		counts[key(r)]++
+	}
+
+	groups := make([]Group, 0, len(counts))
+	for k, count := range counts {
+		groups = append(groups, Group{Key: k, Count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups, nil
+}
+
+func writeGroups(groups []Group, w io.Writer, opts WriteOptions) error {
+	header := []string{opts.GroupBy, "count"}
+	rows := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		rows = append(rows, []string{group.Key, strconv.Itoa(group.Count)})
+	}
+
+	switch opts.Format {
+	case "json":
+		data, err := json.MarshalIndent(groups, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		for _, group := range groups {
+			if err := encoder.Encode(group); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		if err := opts.writeBOM(w); err != nil {
+			return err
+		}
+		writer := opts.csvWriter(w)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		return writer.WriteAll(rows)
+	case "table", "stdout", "txt":
+		return writeTable(w, header, rows)
+	case "tsv":
+		return writeTSV(w, header, rows)
+	default:
+		return fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+}
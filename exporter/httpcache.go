@@ -0,0 +1,169 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiskCache is an http.RoundTripper caching GET responses on disk, one file
+// per request, following their Cache-Control headers. A fresh response is
+// served without contacting Github at all; a stale one is revalidated with
+// its ETag or Last-Modified date, and served again on 304 Not Modified, which
+// does not count against the rate limit. Responses marked no-store are not
+// cached.
+//
+// Requests are keyed by URL and Authorization header, so the responses of
+// one token are never served to another. The files hold response bodies in
+// the clear; keep the directory private.
+type DiskCache struct {
+	// Base is the transport used to make requests. http.DefaultTransport is
+	// used when nil.
+	Base http.RoundTripper
+	// Dir holds the cached responses. It is created on first use.
+	Dir string
+}
+
+// cachedAtHeader records when a cached response was stored or last
+// revalidated, as the reference for its max-age.
+const cachedAtHeader = "X-Github-Exporter-Cached-At"
+
+func (c *DiskCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := c.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet {
+		return base.RoundTrip(req)
+	}
+
+	path := c.path(req)
+	cached, err := readCachedResponse(path, req)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if fresh(cached.Header) {
+			return cached, nil
+		}
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		if cached != nil {
+			cached.Body.Close()
+		}
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		for name, values := range resp.Header {
+			if name == "Cache-Control" || name == "Date" || strings.HasPrefix(name, "X-Ratelimit-") {
+				cached.Header[name] = values
+			}
+		}
+		cached.Request = req
+		return c.store(path, cached)
+
+	case resp.StatusCode == http.StatusOK && !strings.Contains(resp.Header.Get("Cache-Control"), "no-store"):
+		if cached != nil {
+			cached.Body.Close()
+		}
+		return c.store(path, resp)
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+	return resp, nil
+}
+
+// path returns the cache file of req.
+func (c *DiskCache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\x00" + req.Header.Get("Authorization")))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+// store writes resp to path and returns it with its body rewound.
+func (c *DiskCache) store(path string, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Set(cachedAtHeader, time.Now().UTC().Format(time.RFC3339))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, dump, 0600); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// readCachedResponse reads the response cached at path, or nil when there is
+// none. An unreadable entry is treated as missing and overwritten later.
+func readCachedResponse(path string, req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil, nil
+	}
+	return resp, nil
+}
+
+// fresh reports whether a cached response with header is still within the
+// max-age of its Cache-Control header.
+func fresh(header http.Header) bool {
+	cachedAt, err := time.Parse(time.RFC3339, header.Get(cachedAtHeader))
+	if err != nil {
+		return false
+	}
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "no-cache" {
+			return false
+		}
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			maxAge, err := strconv.Atoi(value)
+			if err != nil {
+				return false
+			}
+			return time.Since(cachedAt) < time.Duration(maxAge)*time.Second
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package exporter
+
+import "encoding/json"
+
+// RepoRecords holds the records of a single repository.
+type RepoRecords struct {
+	Commits      []Commit        `json:"commits,omitempty"`
+	PullRequests []PullRequest   `json:"pull_requests,omitempty"`
+	Issues       []Issue         `json:"issues,omitempty"`
+	Releases     []Release       `json:"releases,omitempty"`
+	Watch        []Watch         `json:"watch,omitempty"`
+	CheckRuns    []CheckRun      `json:"check_runs,omitempty"`
+	Deployments  []Deployment    `json:"deployments,omitempty"`
+	Timeline     []TimelineEvent `json:"timeline,omitempty"`
+	Discussions  []Discussion    `json:"discussions,omitempty"`
+
+	Collaborators []Collaborator `json:"collaborators,omitempty"`
+	Stargazers    []Stargazer    `json:"stargazers,omitempty"`
+}
+
+// NestedExport is an Export with its records nested per repository, keyed by
+// repository name, for consumers working through one repository at a time.
+type NestedExport struct {
+	Meta         Meta                    `json:"meta"`
+	Repos        map[string]*RepoRecords `json:"repos"`
+	Repositories []Repository            `json:"repositories,omitempty"`
+	Stats        *Stats                  `json:"stats,omitempty"`
+}
+
+// NestByRepo regroups the records of export per repository.
+func NestByRepo(export Export) NestedExport {
+	nested := NestedExport{Meta: export.Meta, Repos: map[string]*RepoRecords{}, Repositories: export.Repositories, Stats: export.Stats}
+	repo := func(name string) *RepoRecords {
+		if nested.Repos[name] == nil {
+			nested.Repos[name] = &RepoRecords{}
+		}
+		return nested.Repos[name]
+	}
+	for _, commit := range export.Commits {
+		r := repo(commit.Repo)
+		r.Commits = append(r.Commits, commit)
+	}
+	for _, pr := range export.PullRequests {
+		r := repo(pr.Repo)
+		r.PullRequests = append(r.PullRequests, pr)
+	}
+	for _, issue := range export.Issues {
+		r := repo(issue.Repo)
+		r.Issues = append(r.Issues, issue)
+	}
+	for _, release := range export.Releases {
+		r := repo(release.Repo)
+		r.Releases = append(r.Releases, release)
+	}
+	for _, watch := range export.Watch {
+		r := repo(watch.Repo)
+		r.Watch = append(r.Watch, watch)
+	}
+	for _, run := range export.CheckRuns {
+		r := repo(run.Repo)
+		r.CheckRuns = append(r.CheckRuns, run)
+	}
+	for _, deployment := range export.Deployments {
+		r := repo(deployment.Repo)
+		r.Deployments = append(r.Deployments, deployment)
+	}
+	for _, event := range export.Timeline {
+		r := repo(event.Repo)
+		r.Timeline = append(r.Timeline, event)
+	}
+	for _, discussion := range export.Discussions {
+		r := repo(discussion.Repo)
+		r.Discussions = append(r.Discussions, discussion)
+	}
+	for _, collaborator := range export.Collaborators {
+		r := repo(collaborator.Repo)
+		r.Collaborators = append(r.Collaborators, collaborator)
+	}
+	for _, stargazer := range export.Stargazers {
+		r := repo(stargazer.Repo)
+		r.Stargazers = append(r.Stargazers, stargazer)
+	}
+	return nested
+}
+
+// encodeNested encodes the nested form of export as JSON, with opts applied
+// to its records like renameRecords does.
+func encodeNested(export Export, opts WriteOptions) ([]byte, error) {
+	data, err := json.Marshal(NestByRepo(export))
+	if err != nil || (len(opts.Fields) == 0 && !opts.OmitEmpty) {
+		return data, err
+	}
+	return mapObject(data, func(key string, value json.RawMessage) (string, json.RawMessage, error) {
+		if key != "repos" {
+			return key, value, nil
+		}
+		value, err := mapObject(value, func(repo string, records json.RawMessage) (string, json.RawMessage, error) {
+			records, err := encodeLists(records, opts)
+			return repo, records, err
+		})
+		return key, value, err
+	})
+}
@@ -0,0 +1,583 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Formats lists the output formats understood by Write.
+var Formats = []string{"table", "tsv", "json", "ndjson", "csv", "parquet", "prom", "changelog", "template"}
+
+// ValidFormat reports whether Write understands format.
+func ValidFormat(format string) bool {
+	switch format {
+	case "stdout", "txt":
+		return true
+	}
+	return slices.Contains(Formats, format)
+}
+
+// WriteOptions controls how Write renders an export.
+type WriteOptions struct {
+	// Format is one of Formats. "stdout" and "txt" are accepted as aliases
+	// for "table".
+	Format string
+	// Kind selects the records written by the csv and table formats.
+	Kind string
+	// WithBody adds the body column of commits, issues and pull requests to
+	// csv output.
+	WithBody bool
+	// GroupBy writes the number of records per bucket of the given field
+	// (one of GroupByFields) instead of the records themselves.
+	GroupBy string
+	// Fields renames record columns and keys, mapping the name written by
+	// the format to the name to write instead. It applies to the csv, tsv,
+	// table, json and ndjson formats.
+	Fields map[string]string
+	// WithCommits adds the Commits column of pull requests, their SHAs, to
+	// csv output.
+	WithCommits bool
+	// WithCommitter adds the AuthorLogin, CommitterName and CommitterLogin
+	// columns of commits to csv output.
+	WithCommitter bool
+	// WithMember adds the Member column of team exports to the csv, tsv
+	// and table formats.
+	WithMember bool
+	// Append leaves out the csv header of a StreamWriter, for adding records
+	// to an existing export.
+	Append bool
+	// OmitEmpty leaves out the empty fields of records in the json and
+	// ndjson formats: empty strings, zero numbers and dates, false, null and
+	// empty lists. The required fields of a record are always written, even
+	// when empty, so their absence never has to be told apart from a blank.
+	OmitEmpty bool
+	// NestByRepo writes the records of the json format nested per
+	// repository, as a NestedExport.
+	NestByRepo bool
+	// Delimiter separates the fields of the csv format instead of a comma.
+	Delimiter rune
+	// QuoteAll quotes every field of the csv format, rather than only
+	// those holding a delimiter, quote or line break.
+	QuoteAll bool
+	// BOM starts the csv format with a UTF-8 byte order mark, which Excel
+	// needs to read the file as UTF-8.
+	BOM bool
+	// Template is executed against the export by the template format, see
+	// ParseTemplate.
+	Template *template.Template
+	// ShortSHA shortens the commit SHAs of the csv, tsv and table formats
+	// to this many characters. The json formats always keep the full SHA.
+	ShortSHA int
+	// RelativeTime writes the Date column of the table format as the time
+	// elapsed since, such as "3 days ago".
+	RelativeTime bool
+}
+
+// Write renders export to w according to opts.
+func Write(export Export, w io.Writer, opts WriteOptions) error {
+	if opts.GroupBy != "" {
+		groups, err := GroupBy(export, opts.Kind, opts.GroupBy)
+		if err != nil {
+			return err
+		}
+		return writeGroups(groups, w, opts)
+	}
+
+	switch opts.Format {
+	case "json":
+		return writeJSON(export, w, opts)
+	case "ndjson":
+		return writeNDJSON(export, w, opts)
+	case "csv":
+		return writeCSV(export, w, opts)
+	case "parquet":
+		return writeParquet(export, w, opts)
+	case "prom":
+		return writeProm(export, w, opts)
+	case "changelog":
+		return writeChangelog(export, w, opts)
+	case "template":
+		if opts.Template == nil {
+			return fmt.Errorf("the template format requires a template")
+		}
+		return writeTemplate(export, w, opts)
+	case "table", "stdout", "txt":
+		header, rows := tableRows(export, opts)
+		header, err := renameColumns(header, opts.Fields)
+		if err != nil {
+			return err
+		}
+		// Collaborators carry no date to show relative to now.
+		if opts.RelativeTime && opts.Kind != "collaborators" {
+			now := time.Now()
+			for i, r := range records(export, opts.Kind) {
+				rows[i][0] = relativeTime(r.date(), now)
+			}
+		}
+		return writeTable(w, header, rows)
+	case "tsv":
+		header, rows := tableRows(export, opts)
+		header, err := renameColumns(header, opts.Fields)
+		if err != nil {
+			return err
+		}
+		return writeTSV(w, header, rows)
+	default:
+		return fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+}
+
+func writeJSON(export Export, w io.Writer, opts WriteOptions) error {
+	encode := renameRecords
+	if opts.NestByRepo {
+		encode = encodeNested
+	}
+	data, err := encode(export, opts)
+	if err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return err
+	}
+	_, err = indented.WriteTo(w)
+	return err
+}
+
+// writeNDJSON writes the records of opts.Kind as newline delimited JSON, one
+// record per line.
+func writeNDJSON(export Export, w io.Writer, opts WriteOptions) error {
+	for _, record := range records(export, opts.Kind) {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if data, err = encodeRecord(data, record, opts); err != nil {
+			return err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(export Export, w io.Writer, opts WriteOptions) error {
+	if err := opts.writeBOM(w); err != nil {
+		return err
+	}
+	writer := opts.csvWriter(w)
+	defer writer.Flush()
+
+	header, err := csvHeader(opts)
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	return writer.WriteAll(csvRows(export, opts))
+}
+
+// utf8BOM is the UTF-8 byte order mark.
+const utf8BOM = "\ufeff"
+
+// writeBOM writes the byte order mark to w if opts.BOM asks for it.
+func (opts WriteOptions) writeBOM(w io.Writer) error {
+	if !opts.BOM {
+		return nil
+	}
+	_, err := io.WriteString(w, utf8BOM)
+	return err
+}
+
+// recordWriter writes csv records, like a csv.Writer.
+type recordWriter interface {
+	Write(record []string) error
+	WriteAll(records [][]string) error
+	Flush()
+	Error() error
+}
+
+// csvWriter returns a csv writer to w separating fields with opts.Delimiter,
+// and quoting every field with opts.QuoteAll.
+func (opts WriteOptions) csvWriter(w io.Writer) recordWriter {
+	if opts.QuoteAll {
+		comma := opts.Delimiter
+		if comma == 0 {
+			comma = ','
+		}
+		return &quoteAllWriter{w: bufio.NewWriter(w), comma: string(comma)}
+	}
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+	return writer
+}
+
+// quoteAllWriter writes csv records with every field quoted, where a
+// csv.Writer only quotes the fields that need it. Quotes within a field are
+// doubled, so any csv reader reads the same records back.
+type quoteAllWriter struct {
+	w     *bufio.Writer
+	comma string
+	err   error
+}
+
+var csvQuote = strings.NewReplacer(`"`, `""`)
+
+func (q *quoteAllWriter) Write(record []string) error {
+	for i, field := range record {
+		if i > 0 {
+			q.w.WriteString(q.comma)
+		}
+		q.w.WriteString(`"` + csvQuote.Replace(field) + `"`)
+	}
+	_, err := q.w.WriteString("\n")
+	return err
+}
+
+func (q *quoteAllWriter) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := q.Write(record); err != nil {
+			return err
+		}
+	}
+	q.Flush()
+	return q.Error()
+}
+
+func (q *quoteAllWriter) Flush() { q.err = q.w.Flush() }
+
+func (q *quoteAllWriter) Error() error { return q.err }
+
+// csvReader returns a csv reader of r expecting fields separated by
+// opts.Delimiter. A leading byte order mark is skipped.
+func (opts WriteOptions) csvReader(r io.Reader) *csv.Reader {
+	buffered := bufio.NewReader(r)
+	if start, err := buffered.Peek(len(utf8BOM)); err == nil && string(start) == utf8BOM {
+		buffered.Discard(len(utf8BOM))
+	}
+	reader := csv.NewReader(buffered)
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	return reader
+}
+
+// csvHeader returns the csv header row for opts.Kind.
+func csvHeader(opts WriteOptions) ([]string, error) {
+	headers := []string{"Type", "Repo", "ID", "Title", "State", "Author", "Date"}
+	switch opts.Kind {
+	case "commits":
+		headers = append(headers, "Verified", "VerificationReason", "AuthorEmail", "IsMerge")
+		if opts.WithCommitter {
+			headers = append(headers, "AuthorLogin", "CommitterName", "CommitterLogin")
+		}
+	case "pull_requests":
+		headers = append(headers, "Draft", "RequestedReviewers", "RequestedTeams")
+		if opts.WithCommits {
+			headers = append(headers, "Commits")
+		}
+	case "releases":
+		headers = append(headers, "Draft", "Prerelease")
+	case "checks":
+		headers = append(headers, "SHA", "Status")
+	case "deployments":
+		headers = append(headers, "Ref", "SHA")
+	case "timeline":
+		headers = append(headers, "Label", "Assignee", "CommitID")
+	case "discussions":
+		headers = append(headers, "Category")
+	case "collaborators":
+		headers = append(headers, "Permission")
+	}
+	if opts.WithBody && (opts.Kind == "commits" || opts.Kind == "pull_requests" || opts.Kind == "issues" || opts.Kind == "timeline" || opts.Kind == "discussions") {
+		headers = append(headers, "Body")
+	}
+	if opts.WithMember {
+		headers = append(headers, "Member")
+	}
+	return renameColumns(headers, opts.Fields)
+}
+
+// csvRows returns the csv rows of the records of opts.Kind in export.
+func csvRows(export Export, opts WriteOptions) [][]string {
+	var rows [][]string
+
+	switch opts.Kind {
+	case "commits":
+		for _, commit := range export.Commits {
+			row := []string{"Commit", commit.Repo, opts.sha(commit.SHA), commit.Message, "", commit.Author, commit.Date.String(),
+				strconv.FormatBool(commit.Verified), commit.VerificationReason, commit.AuthorEmail, strconv.FormatBool(commit.IsMerge)}
+			if opts.WithCommitter {
+				row = append(row, commit.AuthorLogin, commit.CommitterName, commit.CommitterLogin)
+			}
+			if opts.WithBody {
+				row = append(row, singleLine(commit.Body))
+			}
+			rows = append(rows, row)
+		}
+	case "pull_requests":
+		for _, pr := range export.PullRequests {
+			row := []string{"PullRequest", pr.Repo, fmt.Sprintf("%d", pr.Number), pr.Title, pr.State, pr.Author, pr.Date.String(), strconv.FormatBool(pr.Draft),
+				strings.Join(pr.RequestedReviewers, ";"), strings.Join(pr.RequestedTeams, ";")}
+			if opts.WithCommits {
+				shas := make([]string, len(pr.Commits))
+				for i, commit := range pr.Commits {
+					shas[i] = opts.sha(commit.SHA)
+				}
+				row = append(row, strings.Join(shas, ";"))
+			}
+			if opts.WithBody {
+				row = append(row, singleLine(pr.Body))
+			}
+			rows = append(rows, row)
+		}
+	case "issues":
+		for _, issue := range export.Issues {
+			row := []string{"Issue", issue.Repo, fmt.Sprintf("%d", issue.Number), issue.Title, issue.State, issue.Author, issue.Date.String()}
+			if opts.WithBody {
+				row = append(row, singleLine(issue.Body))
+			}
+			rows = append(rows, row)
+		}
+	case "releases":
+		for _, release := range export.Releases {
+			rows = append(rows, []string{"Release", release.Repo, release.TagName, release.Name, "", release.Author, release.Date.String(),
+				strconv.FormatBool(release.Draft), strconv.FormatBool(release.Prerelease)})
+		}
+	case "watch", "watched":
+		for _, watch := range export.Watch {
+			rows = append(rows, []string{"Watch", watch.Repo, "", "", "", watch.Action, watch.Date.String()})
+		}
+	case "checks":
+		for _, run := range export.CheckRuns {
+			rows = append(rows, []string{"CheckRun", run.Repo, strconv.FormatInt(run.ID, 10), run.Name, run.Conclusion, "", run.CompletedAt.String(),
+				opts.sha(run.SHA), run.Status})
+		}
+	case "deployments":
+		for _, deployment := range export.Deployments {
+			rows = append(rows, []string{"Deployment", deployment.Repo, strconv.FormatInt(deployment.ID, 10), deployment.Environment, "", deployment.Creator, deployment.Date.String(),
+				deployment.Ref, opts.sha(deployment.SHA)})
+		}
+	case "timeline":
+		for _, event := range export.Timeline {
+			row := []string{"TimelineEvent", event.Repo, strconv.FormatInt(event.ID, 10), event.Event, event.State, event.Actor, event.Date.String(),
+				event.Label, event.Assignee, opts.sha(event.CommitID)}
+			if opts.WithBody {
+				row = append(row, singleLine(event.Body))
+			}
+			rows = append(rows, row)
+		}
+	case "discussions":
+		for _, discussion := range export.Discussions {
+			row := []string{"Discussion", discussion.Repo, strconv.Itoa(discussion.Number), discussion.Title, "", discussion.Author, discussion.Date.String(), discussion.Category}
+			if opts.WithBody {
+				row = append(row, singleLine(discussion.Body))
+			}
+			rows = append(rows, row)
+		}
+	case "collaborators":
+		for _, collaborator := range export.Collaborators {
+			rows = append(rows, []string{"Collaborator", collaborator.Repo, collaborator.Login, "", "", collaborator.Login, "", collaborator.Permission})
+		}
+	case "stargazers":
+		for _, stargazer := range export.Stargazers {
+			rows = append(rows, []string{"Stargazer", stargazer.Repo, stargazer.Login, "", "", stargazer.Login, stargazer.StarredAt.String()})
+		}
+	}
+	return opts.memberColumn(export, rows)
+}
+
+// memberColumn appends the Member of each record to its row when
+// opts.WithMember is set. rows must follow the order of records.
+func (opts WriteOptions) memberColumn(export Export, rows [][]string) [][]string {
+	if opts.WithMember {
+		for i, r := range records(export, opts.Kind) {
+			rows[i] = append(rows[i], r.member())
+		}
+	}
+	return rows
+}
+
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.Debug)
+	defer writer.Flush()
+
+	if header == nil {
+		return nil
+	}
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return nil
+}
+
+// writeTSV writes the table columns tab-separated and unaligned, for
+// consumption by other programs.
+func writeTSV(w io.Writer, header []string, rows [][]string) error {
+	if header == nil {
+		return nil
+	}
+	for _, row := range append([][]string{header}, rows...) {
+		for i, field := range row {
+			row[i] = tsvField.Replace(field)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var tsvField = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+// sha returns sha shortened to opts.ShortSHA characters, or whole when
+// ShortSHA is zero.
+func (opts WriteOptions) sha(sha string) string {
+	if opts.ShortSHA > 0 && len(sha) > opts.ShortSHA {
+		return sha[:opts.ShortSHA]
+	}
+	return sha
+}
+
+// tableRows returns the header and rows of the table and tsv formats for
+// opts.Kind. The header is nil for kinds without a table layout.
+func tableRows(export Export, opts WriteOptions) ([]string, [][]string) {
+	header, rows := kindRows(export, opts)
+	if header != nil && opts.WithMember {
+		header = append(header, "Member")
+	}
+	return header, opts.memberColumn(export, rows)
+}
+
+func kindRows(export Export, opts WriteOptions) ([]string, [][]string) {
+	var rows [][]string
+
+	switch opts.Kind {
+	case "commits":
+		for _, commit := range export.Commits {
+			rows = append(rows, []string{commit.Date.String(), commit.Repo, opts.sha(commit.SHA), commit.Author, strconv.FormatBool(commit.Verified), commit.Message})
+		}
+		return []string{"Date", "Repo", "SHA", "Author", "Verified", "Message"}, rows
+	case "pull_requests":
+		for _, pr := range export.PullRequests {
+			rows = append(rows, []string{pr.Date.String(), pr.Repo, strconv.Itoa(pr.Number), pr.Title, pr.State, pr.Author, strconv.FormatBool(pr.Draft)})
+		}
+		return []string{"Date", "Repo", "Number", "Title", "State", "Author", "Draft"}, rows
+	case "issues":
+		for _, issue := range export.Issues {
+			rows = append(rows, []string{issue.Date.String(), issue.Repo, strconv.Itoa(issue.Number), issue.Title, issue.State, issue.Author})
+		}
+		return []string{"Date", "Repo", "Number", "Title", "State", "Author"}, rows
+	case "releases":
+		for _, release := range export.Releases {
+			rows = append(rows, []string{release.Date.String(), release.Repo, release.TagName, release.Name, release.Author,
+				strconv.FormatBool(release.Draft), strconv.FormatBool(release.Prerelease)})
+		}
+		return []string{"Date", "Repo", "Tag", "Name", "Author", "Draft", "Prerelease"}, rows
+	case "watch", "watched":
+		for _, watch := range export.Watch {
+			rows = append(rows, []string{watch.Date.String(), watch.Repo, watch.Action})
+		}
+		return []string{"Date", "Repo", "Action"}, rows
+	case "checks":
+		for _, run := range export.CheckRuns {
+			rows = append(rows, []string{run.CompletedAt.String(), run.Repo, opts.sha(run.SHA), run.Name, run.Status, run.Conclusion})
+		}
+		return []string{"Date", "Repo", "SHA", "Name", "Status", "Conclusion"}, rows
+	case "deployments":
+		for _, deployment := range export.Deployments {
+			rows = append(rows, []string{deployment.Date.String(), deployment.Repo, deployment.Environment, deployment.Ref, opts.sha(deployment.SHA), deployment.Creator})
+		}
+		return []string{"Date", "Repo", "Environment", "Ref", "SHA", "Creator"}, rows
+	case "timeline":
+		for _, event := range export.Timeline {
+			rows = append(rows, []string{event.Date.String(), event.Event, event.Actor, opts.timelineDetail(event)})
+		}
+		return []string{"Date", "Event", "Actor", "Detail"}, rows
+	case "discussions":
+		for _, discussion := range export.Discussions {
+			rows = append(rows, []string{discussion.Date.String(), discussion.Repo, strconv.Itoa(discussion.Number), discussion.Category, discussion.Title, discussion.Author})
+		}
+		return []string{"Date", "Repo", "Number", "Category", "Title", "Author"}, rows
+	case "collaborators":
+		for _, collaborator := range export.Collaborators {
+			rows = append(rows, []string{collaborator.Repo, collaborator.Login, collaborator.Permission})
+		}
+		return []string{"Repo", "Login", "Permission"}, rows
+	case "stargazers":
+		for _, stargazer := range export.Stargazers {
+			rows = append(rows, []string{stargazer.StarredAt.String(), stargazer.Repo, stargazer.Login})
+		}
+		return []string{"Date", "Repo", "Login"}, rows
+	}
+	return nil, nil
+}
+
+// relativeTime describes how long before now t was, in the largest whole
+// unit: "just now", "5 minutes ago", "3 days ago", "2 years ago".
+func relativeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := now.Sub(t)
+	if d < time.Minute {
+		return "just now"
+	}
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"week", 7 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if n := int(d / unit.size); n > 0 {
+			if n == 1 {
+				return "1 " + unit.name + " ago"
+			}
+			return strconv.Itoa(n) + " " + unit.name + "s ago"
+		}
+	}
+	return "just now"
+}
+
+// timelineDetail returns the subject of a timeline event for the table
+// format: the label, assignee, commit or review state it concerns.
+func (opts WriteOptions) timelineDetail(event TimelineEvent) string {
+	switch {
+	case event.Label != "":
+		return event.Label
+	case event.Assignee != "":
+		return event.Assignee
+	case event.CommitID != "":
+		return opts.sha(event.CommitID)
+	}
+	return event.State
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// singleLine replaces the line breaks in s with spaces so multi-line text
+// stays on one csv row.
+func singleLine(s string) string {
+	return lineBreaks.Replace(s)
+}
@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// writeParquet writes the records of opts.Kind as a Parquet file whose
+// schema is derived from their struct: a column per field, named after its
+// json key. Dates are UTC timestamp columns in milliseconds, null when
+// unset, and lists such as the files of commits are LIST columns. The file
+// is compressed with Snappy.
+func writeParquet(export Export, w io.Writer, opts WriteOptions) error {
+	key := opts.Kind
+	switch key {
+	case "checks":
+		key = "check_runs"
+	case "watched":
+		key = "watch"
+	}
+	model, ok := recordTypes[key]
+	if !ok {
+		return fmt.Errorf("unsupported kind for the parquet format: %s", opts.Kind)
+	}
+	rowType := parquetType(reflect.TypeOf(model))
+
+	writer := parquet.NewWriter(w, parquet.SchemaOf(reflect.New(rowType).Interface()), parquet.Compression(&parquet.Snappy))
+	for _, r := range records(export, opts.Kind) {
+		if err := writer.Write(parquetValue(reflect.ValueOf(r), rowType).Interface()); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// parquetTypes caches the row types returned by parquetType.
+var parquetTypes sync.Map
+
+// parquetType returns the type of the Parquet rows of the struct type t: a
+// struct of the exported fields of t, tagged with their json key. Nested
+// structs and slices of them are converted the same way.
+func parquetType(t reflect.Type) reflect.Type {
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return t
+	case t.Kind() == reflect.Slice:
+		return reflect.SliceOf(parquetType(t.Elem()))
+	case t.Kind() != reflect.Struct:
+		return t
+	}
+	if rowType, ok := parquetTypes.Load(t); ok {
+		return rowType.(reflect.Type)
+	}
+
+	var fields []reflect.StructField
+	for _, field := range parquetFields(t) {
+		tag := parquetName(field)
+		switch {
+		case field.Type == reflect.TypeOf(time.Time{}):
+			tag += ",optional,timestamp(millisecond)"
+		case field.Type.Kind() == reflect.Slice:
+			tag += ",list"
+		}
+		fields = append(fields, reflect.StructField{
+			Name: field.Name,
+			Type: parquetType(field.Type),
+			Tag:  reflect.StructTag(`parquet:"` + tag + `"`),
+		})
+	}
+	rowType := reflect.StructOf(fields)
+	parquetTypes.Store(t, rowType)
+	return rowType
+}
+
+// parquetFields returns the fields of the struct type t written to
+// Parquet: the exported ones that are not left out of the json encoding.
+func parquetFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && field.Tag.Get("json") != "-" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// parquetName returns the json key of field.
+func parquetName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// parquetValue copies v into a value of rowType, the parquetType of its
+// type.
+func parquetValue(v reflect.Value, rowType reflect.Type) reflect.Value {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch {
+	case rowType == reflect.TypeOf(time.Time{}):
+		return reflect.ValueOf(v.Interface().(time.Time).UTC())
+	case rowType.Kind() == reflect.Slice:
+		row := reflect.MakeSlice(rowType, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			row.Index(i).Set(parquetValue(v.Index(i), rowType.Elem()))
+		}
+		return row
+	case rowType.Kind() != reflect.Struct:
+		return v
+	}
+
+	row := reflect.New(rowType).Elem()
+	for i, field := range parquetFields(v.Type()) {
+		row.Field(i).Set(parquetValue(v.FieldByIndex(field.Index), rowType.Field(i).Type))
+	}
+	return row
+}
@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WritePatches moves the inline commit patches of export into dir, one
+// <repo>/<sha>.patch file per commit, and replaces the inline patch text with
+// the path of that file. Commits without patches are left untouched.
+func WritePatches(export *Export, dir string) error {
+	for i := range export.Commits {
+		commit := &export.Commits[i]
+
+		var patch strings.Builder
+		for _, file := range commit.Files {
+			if file.Patch == "" {
+				continue
+			}
+			patch.WriteString("diff --git a/" + file.Filename + " b/" + file.Filename + "\n")
+			patch.WriteString(file.Patch)
+			patch.WriteString("\n")
+		}
+		if patch.Len() == 0 {
+			continue
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(commit.Repo), commit.SHA+".patch")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(patch.String()), 0644); err != nil {
+			return err
+		}
+
+		for j := range commit.Files {
+			if commit.Files[j].Patch != "" {
+				commit.Files[j].Patch = ""
+				commit.Files[j].PatchFile = path
+			}
+		}
+	}
+	return nil
+}
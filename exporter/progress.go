@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// progress tracks the per-repository fetch loop and logs an estimate of the
+// remaining time, warning when the rate limit budget looks too small to
+// finish. All methods are no-ops without a logger; the warning goes to
+// warnings.
+type progress struct {
+	logger   *log.Logger
+	warnings *log.Logger
+	total    int
+	done     int
+	requests int
+	start    time.Time
+	rate     github.Rate
+	warned   bool
+}
+
+func newProgress(logger, warnings *log.Logger, total int) *progress {
+	return &progress{logger: logger, warnings: warnings, total: total, start: time.Now()}
+}
+
+// observe records a completed request.
+func (p *progress) observe(resp *github.Response) {
+	if p == nil {
+		return
+	}
+	p.requests++
+	if resp != nil {
+		p.rate = resp.Rate
+	}
+}
+
+// repoDone logs the progress after finishing repo.
+func (p *progress) repoDone(repo string) {
+	p.done++
+	if p.logger == nil {
+		return
+	}
+
+	elapsed := time.Since(p.start)
+	remaining := p.total - p.done
+	eta := elapsed / time.Duration(p.done) * time.Duration(remaining)
+	var latency time.Duration
+	if p.requests > 0 {
+		latency = elapsed / time.Duration(p.requests)
+	}
+	p.logger.Printf("[%d/%d] %s done, %d requests at %s each, rate limit remaining %d, ETA %s",
+		p.done, p.total, repo, p.requests, latency.Round(time.Millisecond), p.rate.Remaining, eta.Round(time.Second))
+
+	if p.warned || p.requests == 0 || remaining == 0 {
+		return
+	}
+	perRepo := float64(p.requests) / float64(p.done)
+	if needed := int(perRepo * float64(remaining)); needed > p.rate.Remaining && p.warnings != nil {
+		p.warned = true
+		pauseAt := p.done + int(float64(p.rate.Remaining)/perRepo) + 1
+		p.warnings.Printf("about %d more requests are needed but only %d remain, expect a rate limit pause around repository %d of %d (limit resets at %s)",
+			needed, p.rate.Remaining, pauseAt, p.total, p.rate.Reset.Format(time.Kitchen))
+	}
+}
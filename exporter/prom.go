@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var promLabelValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeProm writes the number of records of opts.Kind per repository in the
+// Prometheus text exposition format, suitable for the node_exporter textfile
+// collector.
+func writeProm(export Export, w io.Writer, opts WriteOptions) error {
+	counts := map[string]int{}
+	for _, r := range records(export, opts.Kind) {
+		counts[r.repo()]++
+	}
+
+	repos := make([]string, 0, len(counts))
+	for repo := range counts {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	name := "github_" + opts.Kind + "_total"
+	if _, err := fmt.Fprintf(w, "# HELP %s Number of exported %s per repository.\n# TYPE %s gauge\n",
+		name, strings.ReplaceAll(opts.Kind, "_", " "), name); err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if _, err := fmt.Fprintf(w, "%s{repo=\"%s\"} %d\n", name, promLabelValue.Replace(repo), counts[repo]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// RateUsage describes how much of a rate limit an export used. Remaining is
+// taken from the first and last response, Used counts the requests charged
+// against the limit.
+type RateUsage struct {
+	Used           int `json:"used"`
+	StartRemaining int `json:"start_remaining"`
+	EndRemaining   int `json:"end_remaining"`
+}
+
+// RateTracker records the rate limit reported by the responses passing
+// through it, per rate limit resource (core, search, graphql). It should sit
+// below any cache answering requests itself, so conditional requests answered
+// with 304 Not Modified, which are free, are seen as such.
+type RateTracker struct {
+	// Base is the transport used to make requests. http.DefaultTransport is
+	// used when nil.
+	Base http.RoundTripper
+
+	mu    sync.Mutex
+	usage map[string]*RateUsage
+}
+
+func (t *RateTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return resp, nil
+	}
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.usage == nil {
+		t.usage = map[string]*RateUsage{}
+	}
+	usage, ok := t.usage[resource]
+	if !ok {
+		usage = &RateUsage{StartRemaining: remaining}
+		t.usage[resource] = usage
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		usage.Used++
+	}
+	usage.EndRemaining = remaining
+	return resp, nil
+}
+
+// Usage returns the usage of every rate limit resource seen so far.
+func (t *RateTracker) Usage() map[string]RateUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := make(map[string]RateUsage, len(t.usage))
+	for resource, u := range t.usage {
+		usage[resource] = *u
+	}
+	return usage
+}
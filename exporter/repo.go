@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// parseRepo splits a repository given as owner/name, as a bare name, or as a
+// URL such as https://github.com/owner/name, github.com/owner/name/pull/1 or
+// git@github.com:owner/name.git. The owner of a bare name is empty.
+func parseRepo(s string) (owner, name string, err error) {
+	path := strings.TrimSpace(s)
+	switch {
+	case strings.Contains(path, "://"):
+		u, err := url.Parse(path)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid repository %q: %w", s, err)
+		}
+		path = u.Path
+	case strings.HasPrefix(path, "git@"):
+		_, path, _ = strings.Cut(path, ":")
+	default:
+		// A host without a scheme is told apart from an owner by its dot,
+		// which logins cannot contain.
+		if host, rest, ok := strings.Cut(path, "/"); ok && strings.Contains(host, ".") && strings.Contains(rest, "/") {
+			path = rest
+		}
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		name = parts[0]
+	case len(parts) >= 2 && parts[0] != "" && parts[1] != "":
+		owner, name = parts[0], parts[1]
+	default:
+		return "", "", fmt.Errorf("invalid repository %q, expected owner/name", s)
+	}
+	return owner, strings.TrimSuffix(name, ".git"), nil
+}
+
+// normalizeRepos rewrites repos to owner/name, dropping repositories given
+// more than once. Bare names are owned by the authenticated user, who is only
+// looked up when there is one.
+func normalizeRepos(ctx context.Context, client *github.Client, repos []string) ([]string, error) {
+	normalized := make([]string, 0, len(repos))
+	login := ""
+	for _, repo := range repos {
+		owner, name, err := parseRepo(repo)
+		if err != nil {
+			return nil, err
+		}
+		if owner == "" {
+			if login == "" {
+				if login, err = Login(ctx, client); err != nil {
+					return nil, fmt.Errorf("looking up the owner of repository %s: %w", name, err)
+				}
+			}
+			owner = login
+		}
+		// Github compares repository names regardless of case.
+		if slices.ContainsFunc(normalized, func(r string) bool { return strings.EqualFold(r, owner+"/"+name) }) {
+			continue
+		}
+		normalized = append(normalized, owner+"/"+name)
+	}
+	return normalized, nil
+}
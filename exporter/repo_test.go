@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseRepo(t *testing.T) {
+	tests := []struct {
+		in, owner, name string
+		err             bool
+	}{
+		{in: "octo/repo", owner: "octo", name: "repo"},
+		{in: "repo", name: "repo"},
+		{in: "  octo/repo\n", owner: "octo", name: "repo"},
+		{in: "octo/repo/", owner: "octo", name: "repo"},
+		{in: "octo/repo.git", owner: "octo", name: "repo"},
+		{in: "https://github.com/octo/repo", owner: "octo", name: "repo"},
+		{in: "https://github.com/octo/repo.git", owner: "octo", name: "repo"},
+		{in: "https://github.com/octo/repo/pull/1", owner: "octo", name: "repo"},
+		{in: "github.com/octo/repo", owner: "octo", name: "repo"},
+		{in: "git@github.com:octo/repo.git", owner: "octo", name: "repo"},
+		{in: "", err: true},
+		{in: "   ", err: true},
+		{in: "/", err: true},
+		{in: "octo//repo", err: true},
+		{in: "https://github.com/", err: true},
+		{in: "https://github.com/octo%zz/repo", err: true},
+	}
+	for _, test := range tests {
+		owner, name, err := parseRepo(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseRepo(%q) = %q, %q, want an error", test.in, owner, name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRepo(%q): %v", test.in, err)
+			continue
+		}
+		if owner != test.owner || name != test.name {
+			t.Errorf("parseRepo(%q) = %q, %q, want %q, %q", test.in, owner, name, test.owner, test.name)
+		}
+	}
+}
+
+func TestNormalizeRepos(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+		err  bool
+	}{
+		{in: []string{"octo/repo"}, want: "[octo/repo]"},
+		{in: []string{"repo", "other/tool"}, want: "[me/repo other/tool]"},
+		{in: []string{"https://github.com/octo/repo.git", " octo/tool "}, want: "[octo/repo octo/tool]"},
+		{in: []string{"octo/repo", "https://github.com/octo/repo", "Octo/Repo.git"}, want: "[octo/repo]"},
+		{in: []string{"repo", "me/repo"}, want: "[me/repo]"},
+		{in: []string{"octo/repo", "octo//repo"}, err: true},
+	}
+	for _, test := range tests {
+		var lookups int
+		client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lookups++
+			json.NewEncoder(w).Encode(map[string]any{"login": "me"})
+		}))
+		repos, err := normalizeRepos(context.Background(), client, test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("normalizeRepos(%q) = %v, want an error", test.in, repos)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("normalizeRepos(%q): %v", test.in, err)
+			continue
+		}
+		if fmt.Sprint(repos) != test.want {
+			t.Errorf("normalizeRepos(%q) = %v, want %s", test.in, repos, test.want)
+		}
+		if lookups > 1 {
+			t.Errorf("normalizeRepos(%q) looked up the user %d times", test.in, lookups)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// RepoListing describes a repository as considered by an export, for checking
+// the repository filters without fetching any activity.
+type RepoListing struct {
+	FullName string    `json:"full_name"`
+	Stars    int       `json:"stars"`
+	Fork     bool      `json:"fork"`
+	Archived bool      `json:"archived"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// WriteRepositories writes repos to w in format, one of json, csv or table.
+func WriteRepositories(repos []*github.Repository, w io.Writer, format string) error {
+	listings := make([]RepoListing, 0, len(repos))
+	for _, repo := range repos {
+		listings = append(listings, RepoListing{
+			FullName: repo.GetFullName(),
+			Stars:    repo.GetStargazersCount(),
+			Fork:     repo.GetFork(),
+			Archived: repo.GetArchived(),
+			PushedAt: repo.GetPushedAt().Time,
+		})
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(listings, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "csv":
+		writer := csv.NewWriter(w)
+		defer writer.Flush()
+		if err := writer.Write(repoListingHeader); err != nil {
+			return err
+		}
+		return writer.WriteAll(repoListingRows(listings))
+	case "table", "stdout", "txt":
+		return writeTable(w, repoListingHeader, repoListingRows(listings))
+	default:
+		return fmt.Errorf("unsupported format for repository listings: %s", format)
+	}
+}
+
+var repoListingHeader = []string{"Repo", "Stars", "Fork", "Archived", "PushedAt"}
+
+func repoListingRows(listings []RepoListing) [][]string {
+	rows := make([][]string, 0, len(listings))
+	for _, l := range listings {
+		rows = append(rows, []string{l.FullName, strconv.Itoa(l.Stars), strconv.FormatBool(l.Fork),
+			strconv.FormatBool(l.Archived), l.PushedAt.String()})
+	}
+	return rows
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResumeToken records the date of the latest record exported per kind, so a
+// scheduled export can continue where the previous run left off.
+//
+// Its text form is stable: "v1:" followed by comma-separated kind=date
+// pairs in kind order, each date in RFC 3339 UTC, for example
+// "v1:commits=2024-01-05T10:11:12Z,issues=2024-01-04T08:00:00Z".
+type ResumeToken map[string]time.Time
+
+// resumeTokenVersion prefixes the text form of a ResumeToken.
+const resumeTokenVersion = "v1:"
+
+// ParseResumeToken parses the text form of a ResumeToken.
+func ParseResumeToken(s string) (ResumeToken, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), resumeTokenVersion)
+	if !ok {
+		return nil, fmt.Errorf("invalid resume token %q, expected it to start with %s", s, resumeTokenVersion)
+	}
+	token := ResumeToken{}
+	if rest == "" {
+		return token, nil
+	}
+	for _, pair := range strings.Split(rest, ",") {
+		kind, value, ok := strings.Cut(pair, "=")
+		if !ok || kind == "" {
+			return nil, fmt.Errorf("invalid resume token %q, expected kind=date pairs", s)
+		}
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of %s in resume token: %w", kind, err)
+		}
+		token[kind] = date
+	}
+	return token, nil
+}
+
+// String returns the text form of t.
+func (t ResumeToken) String() string {
+	kinds := make([]string, 0, len(t))
+	for kind := range t {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	pairs := make([]string, len(kinds))
+	for i, kind := range kinds {
+		pairs[i] = kind + "=" + t[kind].UTC().Format(time.RFC3339)
+	}
+	return resumeTokenVersion + strings.Join(pairs, ",")
+}
+
+// Since returns the time to export kind from to continue after t: the second
+// after its latest record, or the zero time when t has none.
+func (t ResumeToken) Since(kind string) time.Time {
+	date, ok := t[kind]
+	if !ok {
+		return time.Time{}
+	}
+	return date.Truncate(time.Second).Add(time.Second)
+}
+
+// Observe raises the date of kind in t to that of the latest record of kind
+// in export. Records without a date, such as collaborators, are ignored.
+func (t ResumeToken) Observe(export Export, kind string) {
+	for _, r := range records(export, kind) {
+		if date := r.date(); date.After(t[kind]) {
+			t[kind] = date
+		}
+	}
+}
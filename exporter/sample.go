@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Sampler keeps a uniform random sample of at most n records of a kind out
+// of the batches passed to Add. It uses reservoir sampling, so only the
+// sample is ever held in memory; pass Add as Options.Stream to sample while
+// fetching.
+type Sampler struct {
+	kind string
+	n    int
+	rand *rand.Rand
+	// seen counts the records passed to Add so far.
+	seen   int
+	sample []sampled
+}
+
+// sampled is a record of a sample, with its position among all records to
+// restore the order they were fetched in.
+type sampled struct {
+	seq    int
+	record record
+}
+
+// NewSampler returns a Sampler keeping n records of kind, drawn with seed
+// so the same seed picks the same records of the same export.
+func NewSampler(kind string, n int, seed int64) *Sampler {
+	return &Sampler{kind: kind, n: n, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Add offers the records of the sampled kind in batch to the sample. It
+// never fails; the error return lets it serve as Options.Stream.
+func (s *Sampler) Add(batch Export) error {
+	for _, r := range records(batch, s.kind) {
+		s.seen++
+		if len(s.sample) < s.n {
+			s.sample = append(s.sample, sampled{s.seen, r})
+		} else if i := s.rand.Intn(s.seen); i < s.n {
+			s.sample[i] = sampled{s.seen, r}
+		}
+	}
+	return nil
+}
+
+// Seen returns the number of records offered to the sample so far.
+func (s *Sampler) Seen() int { return s.seen }
+
+// Sample adds the records of export to the sample, then replaces them with
+// the sample, in the order they were fetched in.
+func (s *Sampler) Sample(export *Export) {
+	s.Add(*export)
+	sort.Slice(s.sample, func(i, j int) bool { return s.sample[i].seq < s.sample[j].seq })
+
+	sample := Export{}
+	for _, sampled := range s.sample {
+		sample.addRecord(sampled.record)
+	}
+	switch s.kind {
+	case "commits":
+		export.Commits = sample.Commits
+	case "pull_requests":
+		export.PullRequests = sample.PullRequests
+	case "issues":
+		export.Issues = sample.Issues
+	case "releases":
+		export.Releases = sample.Releases
+	case "watch", "watched":
+		export.Watch = sample.Watch
+	case "checks":
+		export.CheckRuns = sample.CheckRuns
+	case "deployments":
+		export.Deployments = sample.Deployments
+	case "timeline":
+		export.Timeline = sample.Timeline
+	case "discussions":
+		export.Discussions = sample.Discussions
+	case "collaborators":
+		export.Collaborators = sample.Collaborators
+	case "stargazers":
+		export.Stargazers = sample.Stargazers
+	}
+}
+
+// addRecord appends r to the records of its type in export.
+func (export *Export) addRecord(r record) {
+	switch r := r.(type) {
+	case Commit:
+		export.Commits = append(export.Commits, r)
+	case PullRequest:
+		export.PullRequests = append(export.PullRequests, r)
+	case Issue:
+		export.Issues = append(export.Issues, r)
+	case Release:
+		export.Releases = append(export.Releases, r)
+	case Watch:
+		export.Watch = append(export.Watch, r)
+	case CheckRun:
+		export.CheckRuns = append(export.CheckRuns, r)
+	case Deployment:
+		export.Deployments = append(export.Deployments, r)
+	case TimelineEvent:
+		export.Timeline = append(export.Timeline, r)
+	case Discussion:
+		export.Discussions = append(export.Discussions, r)
+	case Collaborator:
+		export.Collaborators = append(export.Collaborators, r)
+	case Stargazer:
+		export.Stargazers = append(export.Stargazers, r)
+	}
+}
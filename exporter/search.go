@@ -0,0 +1,186 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// fetchGitHubSearch exports issues and pull requests across all repositories
+// with the search API, which takes far fewer requests than listing them per
+// repository. A raw opts.Query is passed to the issue search as is, or to the
+// commit search for the commits kind. The search API has its own, lower rate
+// limit; rate limited responses are waited out by RetryTransport like any
+// other.
+func fetchGitHubSearch(ctx context.Context, client *github.Client, author string, opts Options) (Export, error) {
+	export := Export{}
+	export.Meta = newMeta(author, opts)
+
+	var err error
+	if opts.Query != "" {
+		if opts.Kind == "commits" {
+			err = searchCommits(ctx, client, opts.Query, opts, &export)
+		} else {
+			err = searchIssues(ctx, client, opts.Query, opts, &export)
+		}
+		return export, err
+	}
+
+	query := []string{"author:" + author}
+	switch opts.Kind {
+	case "issues":
+		query = append(query, "is:issue")
+	case "pull_requests":
+		query = append(query, "is:pr")
+		if opts.MergedOnly {
+			query = append(query, "is:merged")
+		}
+	default:
+		return export, fmt.Errorf("unsupported kind for search mode: %s", opts.Kind)
+	}
+	for _, repo := range opts.Repos {
+		query = append(query, "repo:"+repo)
+	}
+	for _, label := range opts.Labels {
+		query = append(query, fmt.Sprintf("label:%q", label))
+	}
+	qualifier := "created:"
+	if opts.MergedOnly && opts.Kind == "pull_requests" {
+		qualifier = "merged:"
+	}
+	switch {
+	case !opts.Since.IsZero() && !opts.Until.IsZero():
+		query = append(query, qualifier+opts.Since.Format("2006-01-02")+".."+opts.Until.Format("2006-01-02"))
+	case !opts.Since.IsZero():
+		query = append(query, qualifier+">="+opts.Since.Format("2006-01-02"))
+	case !opts.Until.IsZero():
+		query = append(query, qualifier+"<="+opts.Until.Format("2006-01-02"))
+	}
+
+	err = searchIssues(ctx, client, strings.Join(query, " "), opts, &export)
+	return export, err
+}
+
+// searchIssues runs an issue search and adds every result to export,
+// handing each page to opts.Stream when streaming.
+func searchIssues(ctx context.Context, client *github.Client, query string, opts Options, export *Export) error {
+	searchOpt := &github.SearchOptions{
+		Sort:        "created",
+		Order:       "desc",
+		ListOptions: github.ListOptions{PerPage: opts.perPage()},
+	}
+	for page := 1; ; page++ {
+		result, resp, err := client.Search.Issues(ctx, query, searchOpt)
+		if err != nil {
+			return err
+		}
+		opts.Stats.page("", "issue search", resp, page, len(result.Issues))
+
+		for _, issue := range result.Issues {
+			if issue.IsPullRequest() && !opts.wantPullRequest(issue.GetCreatedAt().Time, issue.GetPullRequestLinks().GetMergedAt().Time) {
+				continue
+			}
+			if !issue.IsPullRequest() && !opts.inWindow(issue.GetCreatedAt().Time) {
+				continue
+			}
+			if issue.IsPullRequest() && !opts.wantDraft(issue.GetDraft()) {
+				continue
+			}
+			repo := repoFromURL(issue.GetRepositoryURL())
+			if issue.IsPullRequest() {
+				export.PullRequests = append(export.PullRequests, PullRequest{
+					Repo:   repo,
+					Number: issue.GetNumber(),
+					Title:  issue.GetTitle(),
+					State:  issue.GetState(),
+					Draft:  issue.GetDraft(),
+					Author: issue.GetUser().GetLogin(),
+					Date:   issue.GetCreatedAt().Time,
+					Body:   opts.body(issue.GetBody()),
+
+					Labels:   labelNames(issue.Labels),
+					MergedAt: issue.GetPullRequestLinks().GetMergedAt().Time,
+				})
+				if err := opts.addPullRequestCommits(ctx, client, &export.PullRequests[len(export.PullRequests)-1]); err != nil {
+					return err
+				}
+			} else {
+				export.Issues = append(export.Issues, Issue{
+					Repo:   repo,
+					Number: issue.GetNumber(),
+					Title:  issue.GetTitle(),
+					State:  issue.GetState(),
+					Author: issue.GetUser().GetLogin(),
+					Date:   issue.GetCreatedAt().Time,
+					Body:   opts.body(issue.GetBody()),
+				})
+			}
+		}
+
+		if err := opts.flush(export); err != nil {
+			return err
+		}
+		if !opts.morePages(resp, page, "issue search results") {
+			return nil
+		}
+		searchOpt.Page = resp.NextPage
+	}
+}
+
+// searchCommits runs a commit search and adds every result to export,
+// handing each page to opts.Stream when streaming.
+func searchCommits(ctx context.Context, client *github.Client, query string, opts Options, export *Export) error {
+	searchOpt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: opts.perPage()}}
+	for page := 1; ; page++ {
+		result, resp, err := client.Search.Commits(ctx, query, searchOpt)
+		if err != nil {
+			return err
+		}
+		opts.Stats.page("", "commit search", resp, page, len(result.Commits))
+
+		for _, commit := range result.Commits {
+			isMerge := len(commit.Parents) > 1
+			if opts.ExcludeMerges && isMerge {
+				continue
+			}
+			c := Commit{
+				Repo:               commit.GetRepository().GetFullName(),
+				SHA:                commit.GetSHA(),
+				Message:            commit.GetCommit().GetMessage(),
+				Author:             commit.GetCommit().GetAuthor().GetName(),
+				AuthorEmail:        opts.authorEmail(commit.GetCommit().GetAuthor().GetEmail()),
+				AuthorLogin:        commit.GetAuthor().GetLogin(),
+				CommitterName:      commit.GetCommit().GetCommitter().GetName(),
+				CommitterLogin:     commit.GetCommitter().GetLogin(),
+				Date:               commit.GetCommit().GetAuthor().GetDate().Time,
+				Verified:           commit.GetCommit().GetVerification().GetVerified(),
+				VerificationReason: commit.GetCommit().GetVerification().GetReason(),
+				IsMerge:            isMerge,
+			}
+			if opts.WithCoAuthors {
+				c.CoAuthors = parseCoAuthors(c.Message)
+			}
+			export.Commits = append(export.Commits, c)
+		}
+
+		if err := opts.flush(export); err != nil {
+			return err
+		}
+		if !opts.morePages(resp, page, "commit search results") {
+			return nil
+		}
+		searchOpt.Page = resp.NextPage
+	}
+}
+
+// repoFromURL returns the owner/name of the repository an API URL such as
+// https://api.github.com/repos/owner/name points to.
+func repoFromURL(url string) string {
+	_, repo, found := strings.Cut(url, "/repos/")
+	if !found {
+		return ""
+	}
+	return repo
+}
@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"sync"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// Stats counts the pages and records fetched per repository and listing, to
+// debug exports missing records.
+type Stats struct {
+	mu       sync.Mutex
+	Listings []ListingStats `json:"listings"`
+}
+
+// ListingStats describes one listing of an export. Repo is empty for the
+// listings not made per repository, such as events and search results.
+// Records counts the items Github returned, before any filtering. Capped is
+// set when the listing reached Options.MaxPages. TotalPages is the number of
+// pages Github announced in the Link header of the first page, summed over
+// the listings made more than once per repository such as pull request
+// commits; fewer Pages means the listing stopped early. It is zero when
+// Github announced no total, as for GraphQL listings.
+type ListingStats struct {
+	Repo       string `json:"repo,omitempty"`
+	Listing    string `json:"listing"`
+	Pages      int    `json:"pages"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	Records    int    `json:"records"`
+	Capped     bool   `json:"capped,omitempty"`
+}
+
+// page records the page-th page, of n items, of the listing of repo, which
+// Github answered with resp. It is a no-op on a nil Stats.
+func (s *Stats) page(repo, listing string, resp *github.Response, page, n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var l *ListingStats
+	for i := range s.Listings {
+		if s.Listings[i].Repo == repo && s.Listings[i].Listing == listing {
+			l = &s.Listings[i]
+			break
+		}
+	}
+	if l == nil {
+		s.Listings = append(s.Listings, ListingStats{Repo: repo, Listing: listing})
+		l = &s.Listings[len(s.Listings)-1]
+	}
+	l.Pages++
+	l.Records += n
+	if page == 1 {
+		l.TotalPages += lastPage(resp, page)
+	}
+}
+
+// lastPage returns the number of pages of the listing resp is the page-th
+// page of, from the rel="last" link of its Link header, or 0 when unknown.
+// The last page has no rel="last" link, but no rel="next" one either.
+func lastPage(resp *github.Response, page int) int {
+	switch {
+	case resp == nil:
+		return 0
+	case resp.LastPage != 0:
+		return resp.LastPage
+	case resp.NextPage == 0:
+		return page
+	}
+	return 0
+}
+
+// Records returns the number of items Github returned across all listings,
+// before any filtering.
+func (s *Stats) Records() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, l := range s.Listings {
+		n += l.Records
+	}
+	return n
+}
+
+// markCapped flags the listings that reached maxPages.
+func (s *Stats) markCapped(maxPages int) {
+	if s == nil || maxPages == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.Listings {
+		s.Listings[i].Capped = s.Listings[i].Pages >= maxPages
+	}
+}
@@ -0,0 +1,93 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Streams reports whether opts can be written incrementally with a
+// StreamWriter. Only the record-per-line formats without grouping can.
+func Streams(opts WriteOptions) bool {
+	if opts.GroupBy != "" {
+		return false
+	}
+	return opts.Format == "ndjson" || opts.Format == "csv"
+}
+
+// StreamWriter writes the batches of records passed to Options.Stream as they
+// are fetched, so the export never has to be held in memory as a whole.
+type StreamWriter struct {
+	w    io.Writer
+	opts WriteOptions
+	csv  recordWriter
+}
+
+// NewStreamWriter returns a StreamWriter rendering to w according to opts,
+// which must satisfy Streams. The csv header, and byte order mark with
+// opts.BOM, are written right away unless opts.Append is set.
+func NewStreamWriter(w io.Writer, opts WriteOptions) (*StreamWriter, error) {
+	if !Streams(opts) {
+		return nil, fmt.Errorf("format %s cannot be streamed", opts.Format)
+	}
+	s := &StreamWriter{w: w, opts: opts}
+	if opts.Format == "csv" {
+		s.csv = opts.csvWriter(w)
+		if opts.Append {
+			return s, nil
+		}
+		header, err := csvHeader(opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := opts.writeBOM(w); err != nil {
+			return nil, err
+		}
+		if err := s.csv.Write(header); err != nil {
+			return nil, err
+		}
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// CheckAppend reads the start of an existing export in r and fails unless
+// records written with opts can be appended to it. A csv export must have
+// the columns opts writes, which differ for exports by an older version, with
+// another --fields-map or a different set of optional columns. ndjson lines
+// each name their own fields, so older lines and newer ones mix safely.
+func CheckAppend(r io.Reader, opts WriteOptions) error {
+	if opts.Format != "csv" {
+		return nil
+	}
+	want, err := csvHeader(opts)
+	if err != nil {
+		return err
+	}
+	header, err := opts.csvReader(r).Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(header, want) {
+		return fmt.Errorf("the existing csv columns (%s) differ from those written now (%s), start a new file",
+			strings.Join(header, ","), strings.Join(want, ","))
+	}
+	return nil
+}
+
+// Write renders the records of batch. It has the signature of
+// Options.Stream.
+func (s *StreamWriter) Write(batch Export) error {
+	if s.csv == nil {
+		return writeNDJSON(batch, s.w, s.opts)
+	}
+	return s.csv.WriteAll(csvRows(batch, s.opts))
+}
@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"context"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// TeamMembers returns the logins of the members of the team slug in org,
+// listed with the page size and limit of opts.
+func TeamMembers(ctx context.Context, client *github.Client, org, slug string, opts Options) ([]string, error) {
+	var logins []string
+
+	opt := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: opts.perPage()}}
+	for page := 1; ; page++ {
+		members, resp, err := client.Teams.ListTeamMembersBySlug(ctx, org, slug, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			logins = append(logins, member.GetLogin())
+		}
+
+		if !opts.morePages(resp, page, "members of "+org+"/"+slug) {
+			return logins, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// add appends the records of other to export.
+func (export *Export) add(other Export) {
+	export.Commits = append(export.Commits, other.Commits...)
+	export.PullRequests = append(export.PullRequests, other.PullRequests...)
+	export.Issues = append(export.Issues, other.Issues...)
+	export.Releases = append(export.Releases, other.Releases...)
+	export.Watch = append(export.Watch, other.Watch...)
+	export.CheckRuns = append(export.CheckRuns, other.CheckRuns...)
+	export.Deployments = append(export.Deployments, other.Deployments...)
+	export.Timeline = append(export.Timeline, other.Timeline...)
+	export.Discussions = append(export.Discussions, other.Discussions...)
+	export.Collaborators = append(export.Collaborators, other.Collaborators...)
+	export.Stargazers = append(export.Stargazers, other.Stargazers...)
+	for repo, tag := range other.LatestReleases {
+		if export.LatestReleases == nil {
+			export.LatestReleases = map[string]string{}
+		}
+		export.LatestReleases[repo] = tag
+	}
+}
+
+// tagMember sets Member on the records of export while a team export fetches
+// one of its members.
+func (opts Options) tagMember(export *Export) {
+	if opts.member == "" {
+		return
+	}
+	for i := range export.Commits {
+		export.Commits[i].Member = opts.member
+	}
+	for i := range export.PullRequests {
+		export.PullRequests[i].Member = opts.member
+	}
+	for i := range export.Issues {
+		export.Issues[i].Member = opts.member
+	}
+	for i := range export.Releases {
+		export.Releases[i].Member = opts.member
+	}
+	for i := range export.Watch {
+		export.Watch[i].Member = opts.member
+	}
+	for i := range export.CheckRuns {
+		export.CheckRuns[i].Member = opts.member
+	}
+	for i := range export.Deployments {
+		export.Deployments[i].Member = opts.member
+	}
+	for i := range export.Timeline {
+		export.Timeline[i].Member = opts.member
+	}
+	for i := range export.Discussions {
+		export.Discussions[i].Member = opts.member
+	}
+	for i := range export.Collaborators {
+		export.Collaborators[i].Member = opts.member
+	}
+	for i := range export.Stargazers {
+		export.Stargazers[i].Member = opts.member
+	}
+}
@@ -0,0 +1,31 @@
+package exporter
+
+import (
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the helper functions available to templates parsed by
+// ParseTemplate.
+var templateFuncs = template.FuncMap{
+	// date formats a time with a Go layout, such as "2006-01-02".
+	"date": func(layout string, t time.Time) string { return t.Format(layout) },
+	// join joins a list of strings with a separator.
+	"join": func(sep string, elems []string) string { return strings.Join(elems, sep) },
+}
+
+// ParseTemplate parses text as a text/template for the template format,
+// with the date and join helper functions:
+//
+//	{{range .Commits}}{{date "2006-01-02" .Date}} {{.Repo}} {{join ", " .CoAuthors}}
+//	{{end}}
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// writeTemplate executes opts.Template against export.
+func writeTemplate(export Export, w io.Writer, opts WriteOptions) error {
+	return opts.Template.Execute(w, export)
+}
@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// fetchTimeline exports the timeline of issue or pull request opts.Number in
+// the single repository of opts.Repos, in chronological order.
+func fetchTimeline(ctx context.Context, client *github.Client, login string, opts Options) (Export, error) {
+	export := Export{}
+	export.Meta = newMeta(login, opts)
+
+	if len(opts.Repos) != 1 {
+		return export, fmt.Errorf("the timeline kind needs exactly one repository")
+	}
+	owner, name, err := parseRepo(opts.Repos[0])
+	if err != nil {
+		return export, err
+	}
+
+	opt := &github.ListOptions{PerPage: opts.perPage()}
+	for page := 1; ; page++ {
+		events, resp, err := client.Issues.ListIssueTimeline(ctx, owner, name, opts.Number, opt)
+		if err != nil {
+			return export, err
+		}
+		opts.Stats.page(opts.Repos[0], "timeline", resp, page, len(events))
+		for _, event := range events {
+			export.Timeline = append(export.Timeline, timelineEvent(opts.Repos[0], event, opts))
+		}
+
+		if !opts.morePages(resp, page, "the timeline of #"+fmt.Sprint(opts.Number)) {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	// Referenced commits carry their commit date, which can predate the
+	// events listed before them.
+	sort.SliceStable(export.Timeline, func(i, j int) bool {
+		return export.Timeline[i].Date.Before(export.Timeline[j].Date)
+	})
+	return export, nil
+}
+
+// timelineEvent converts an event of the timeline of an issue in repo.
+func timelineEvent(repo string, event *github.Timeline, opts Options) TimelineEvent {
+	e := TimelineEvent{
+		Repo:     repo,
+		Number:   opts.Number,
+		ID:       event.GetID(),
+		Event:    event.GetEvent(),
+		Actor:    event.GetActor().GetLogin(),
+		Date:     event.GetCreatedAt().Time,
+		Label:    event.GetLabel().GetName(),
+		Assignee: event.GetAssignee().GetLogin(),
+		CommitID: event.GetCommitID(),
+		State:    event.GetState(),
+		Body:     opts.body(event.GetBody()),
+	}
+	// Comments name their author in user, reviews their submission time in
+	// submitted_at, and commits both in author.
+	if e.Actor == "" {
+		e.Actor = event.GetUser().GetLogin()
+	}
+	if e.Date.IsZero() {
+		e.Date = event.GetSubmittedAt().Time
+	}
+	if event.GetEvent() == "committed" {
+		e.Actor = event.GetAuthor().GetName()
+		e.Date = event.GetAuthor().GetDate().Time
+		e.CommitID = event.GetSHA()
+	}
+	return e
+}
@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TokenTransport authenticates requests with one of several tokens, rotating
+// through them round-robin to spread requests over their rate limits. Tokens
+// whose limit is exhausted are skipped until it resets, and a GET request that
+// hits the rate limit is retried right away with the next available token.
+type TokenTransport struct {
+	// Base is the transport used to make requests. http.DefaultTransport is
+	// used when nil.
+	Base http.RoundTripper
+
+	mu     sync.Mutex
+	tokens []tokenState
+	next   int
+}
+
+type tokenState struct {
+	token     string
+	remaining int
+	reset     time.Time
+}
+
+// NewTokenTransport returns a transport rotating through tokens.
+func NewTokenTransport(tokens []string, base http.RoundTripper) *TokenTransport {
+	t := &TokenTransport{Base: base}
+	for _, token := range tokens {
+		t.tokens = append(t.tokens, tokenState{token: token, remaining: -1})
+	}
+	return t
+}
+
+func (t *TokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	attempts := 1
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		attempts = len(t.tokens)
+	}
+
+	var resp *http.Response
+	for attempt := 0; attempt < attempts; attempt++ {
+		i, ok := t.pick()
+		if attempt > 0 {
+			if !ok {
+				// Every token is exhausted, leave the wait to the caller.
+				break
+			}
+			resp.Body.Close()
+		}
+
+		authed := req.Clone(req.Context())
+		authed.Header.Set("Authorization", "Bearer "+t.tokens[i].token)
+
+		var err error
+		resp, err = base.RoundTrip(authed)
+		if err != nil {
+			return nil, err
+		}
+		t.update(i, resp)
+
+		if !isRateLimited(resp) {
+			break
+		}
+	}
+	return resp, nil
+}
+
+// Remaining returns the remaining rate limit last reported for each token,
+// in the order they were given. -1 means the token has not been used yet.
+func (t *TokenTransport) Remaining() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remaining := make([]int, len(t.tokens))
+	for i, state := range t.tokens {
+		remaining[i] = state.remaining
+	}
+	return remaining
+}
+
+// pick returns the index of the next token with rate limit left. When every
+// token is exhausted it returns the one that resets first and false.
+func (t *TokenTransport) pick() (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for n := 0; n < len(t.tokens); n++ {
+		i := (t.next + n) % len(t.tokens)
+		state := t.tokens[i]
+		if state.remaining != 0 || now.After(state.reset) {
+			t.next = i + 1
+			return i, true
+		}
+	}
+
+	first := 0
+	for i, state := range t.tokens {
+		if state.reset.Before(t.tokens[first].reset) {
+			first = i
+		}
+	}
+	return first, false
+}
+
+func (t *TokenTransport) update(i int, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokens[i].remaining = remaining
+	t.tokens[i].reset = time.Unix(reset, 0)
+}
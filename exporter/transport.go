@@ -0,0 +1,186 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// RetryTransport retries idempotent requests that fail with a transient 5xx
+// status, a network error or that hit the Github rate limit. Server and
+// network errors are retried with exponential backoff and jitter; rate
+// limited requests wait for the limit to reset. Since the failed request
+// itself is retried, a paginated listing resumes from the page that failed
+// and keeps the pages fetched before it.
+type RetryTransport struct {
+	// Base is the transport used to make requests. http.DefaultTransport is
+	// used when nil.
+	Base http.RoundTripper
+	// MaxRetries bounds how often a single request is retried.
+	MaxRetries int
+	// MaxWait bounds a single wait before retrying, such as for a rate
+	// limit reset an hour away. A request that would wait longer fails with
+	// its response instead. Zero means no bound.
+	MaxWait time.Duration
+	// Timeout bounds every attempt of a request, reading its response body
+	// included, so a hung connection fails and is retried rather than
+	// blocking the export. Waits between attempts do not count. Zero means
+	// no bound.
+	Timeout time.Duration
+	// Logger receives a line for every retry. Logging is disabled when nil.
+	Logger *log.Logger
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.attempt(base, req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(base, req)
+		if attempt >= t.MaxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		var wait time.Duration
+		var reason string
+		if err != nil {
+			wait, reason = backoff(attempt), err.Error()
+		} else {
+			var retry bool
+			if wait, retry = retryDelay(resp, attempt); !retry {
+				return resp, nil
+			}
+			if t.MaxWait > 0 && wait > t.MaxWait {
+				t.logf("%s %s: %s, not retrying, the wait of %s exceeds the maximum of %s",
+					req.Method, req.URL.Path, resp.Status, wait.Round(time.Second), t.MaxWait)
+				return resp, nil
+			}
+			resp.Body.Close()
+			reason = resp.Status
+		}
+		t.logf("%s %s: %s, retrying in %s (attempt %d/%d)",
+			req.Method, req.URL.Path, reason, wait.Round(time.Millisecond), attempt+1, t.MaxRetries)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes a single attempt at req, bounded by t.Timeout.
+func (t *RetryTransport) attempt(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if t.Timeout <= 0 {
+		return base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.Timeout)
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the timeout of an attempt once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func (t *RetryTransport) logf(format string, args ...any) {
+	if t.Logger != nil {
+		t.Logger.Printf(format, args...)
+	}
+}
+
+// retryDelay reports whether resp should be retried and how long to wait
+// before doing so.
+func retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	if isRateLimited(resp) {
+		if after, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(after) * time.Second, true
+		}
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0)) + time.Second, true
+		}
+		return backoff(attempt), true
+	}
+	if resp.StatusCode >= 500 {
+		return backoff(attempt), true
+	}
+	return 0, false
+}
+
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// backoff returns the exponential delay for attempt, jittered to avoid
+// retrying in lockstep.
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// DebugTransport logs every request with its response status, duration and
+// the remaining rate limit. Request headers are included with the
+// Authorization header redacted.
+type DebugTransport struct {
+	// Base is the transport used to make requests. http.DefaultTransport is
+	// used when nil.
+	Base   http.RoundTripper
+	Logger *log.Logger
+}
+
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	header := req.Header.Clone()
+	if header.Get("Authorization") != "" {
+		header.Set("Authorization", "REDACTED")
+	}
+	t.Logger.Printf("--> %s %s %v", req.Method, req.URL, header)
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		t.Logger.Printf("<-- %s %s error after %s: %v", req.Method, req.URL, time.Since(start).Round(time.Millisecond), err)
+		return resp, err
+	}
+	t.Logger.Printf("<-- %s %s %s in %s (rate limit remaining: %s)", req.Method, req.URL, resp.Status,
+		time.Since(start).Round(time.Millisecond), resp.Header.Get("X-RateLimit-Remaining"))
+	return resp, nil
+}
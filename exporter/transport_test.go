@@ -0,0 +1,129 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+func TestRetryTransportResumesListing(t *testing.T) {
+	const pages, perPage = 4, 2
+	newest := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	requested := map[int]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/user" {
+			json.NewEncoder(w).Encode(map[string]any{"login": "octo"})
+			return
+		}
+
+		// The connection drops the first time the third page is asked
+		// for.
+		page := requestPage(r)
+		mu.Lock()
+		requested[page]++
+		failed := page == 3 && requested[page] == 1
+		mu.Unlock()
+		if failed {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+			return
+		}
+
+		var events []map[string]any
+		for i := (page - 1) * perPage; i < page*perPage; i++ {
+			events = append(events, map[string]any{
+				"type":       "IssuesEvent",
+				"actor":      map[string]any{"login": "octo"},
+				"repo":       map[string]any{"name": "octo/repo"},
+				"created_at": newest.AddDate(0, 0, -i),
+				"payload":    map[string]any{"action": "opened", "issue": map[string]any{"number": i + 1}},
+			})
+		}
+		pageLinks(w, r, page, pages)
+		json.NewEncoder(w).Encode(events)
+	}))
+	t.Cleanup(server.Close)
+
+	// Without keep-alive net/http cannot retry the dropped connection
+	// itself, as it does for reused connections.
+	base := &http.Transport{DisableKeepAlives: true}
+	client := github.NewClient(&http.Client{Transport: &RetryTransport{Base: base, MaxRetries: 2}})
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	opts := Options{Kind: "issues", Mode: "events"}
+	export, err := Fetch(context.Background(), client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[int]bool{}
+	for _, issue := range export.Issues {
+		if seen[issue.Number] {
+			t.Errorf("issue %d exported twice", issue.Number)
+		}
+		seen[issue.Number] = true
+	}
+	if len(seen) != pages*perPage {
+		t.Errorf("exported %d distinct issues, want all %d", len(seen), pages*perPage)
+	}
+
+	// Only the failed page is fetched again.
+	mu.Lock()
+	defer mu.Unlock()
+	for page, want := range map[int]int{1: 1, 2: 1, 3: 2, 4: 1} {
+		if requested[page] != want {
+			t.Errorf("page %d requested %d times, want %d", page, requested[page], want)
+		}
+	}
+}
+
+func TestRetryTransportTimeout(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		hang := requests == 1
+		mu.Unlock()
+		// The first connection hangs until the client gives up on it.
+		if hang {
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: &RetryTransport{MaxRetries: 1, Timeout: 50 * time.Millisecond}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("body %q, want the retried response", body)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 2 {
+		t.Errorf("served %d requests, want 2", requests)
+	}
+}
@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// required returns the names of the required fields of a record, each
+// followed by its value, which is empty when the field is.
+func (c Commit) required() []string {
+	return []string{"repo", c.Repo, "sha", c.SHA, "author", c.Author, "date", dateField(c.Date.IsZero())}
+}
+
+func (pr PullRequest) required() []string {
+	return []string{"repo", pr.Repo, "number", numberField(pr.Number), "title", pr.Title, "author", pr.Author, "date", dateField(pr.Date.IsZero())}
+}
+
+func (i Issue) required() []string {
+	return []string{"repo", i.Repo, "number", numberField(i.Number), "title", i.Title, "author", i.Author, "date", dateField(i.Date.IsZero())}
+}
+
+func (r Release) required() []string {
+	return []string{"repo", r.Repo, "tag_name", r.TagName, "date", dateField(r.Date.IsZero())}
+}
+
+func (w Watch) required() []string {
+	return []string{"repo", w.Repo}
+}
+
+// The completion time of a check run is only known once it has completed.
+func (cr CheckRun) required() []string {
+	return []string{"repo", cr.Repo, "id", numberField(int(cr.ID)), "sha", cr.SHA, "name", cr.Name, "status", cr.Status}
+}
+
+func (d Deployment) required() []string {
+	return []string{"repo", d.Repo, "id", numberField(int(d.ID)), "environment", d.Environment, "ref", d.Ref, "date", dateField(d.Date.IsZero())}
+}
+
+func (e TimelineEvent) required() []string {
+	return []string{"repo", e.Repo, "number", numberField(e.Number), "event", e.Event, "date", dateField(e.Date.IsZero())}
+}
+
+func (d Discussion) required() []string {
+	return []string{"repo", d.Repo, "number", numberField(d.Number), "title", d.Title, "date", dateField(d.Date.IsZero())}
+}
+
+func (c Collaborator) required() []string {
+	return []string{"repo", c.Repo, "login", c.Login, "permission", c.Permission}
+}
+
+func (s Stargazer) required() []string {
+	return []string{"repo", s.Repo, "login", s.Login, "starred_at", dateField(s.StarredAt.IsZero())}
+}
+
+// missing returns the names of the required fields left empty in r.
+func missing(r record) []string {
+	var missing []string
+	pairs := r.required()
+	for i := 0; i < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			missing = append(missing, pairs[i])
+		}
+	}
+	return missing
+}
+
+// requiredNames returns the names of the required fields of r.
+func requiredNames(r record) []string {
+	var names []string
+	pairs := r.required()
+	for i := 0; i < len(pairs); i += 2 {
+		names = append(names, pairs[i])
+	}
+	return names
+}
+
+func dateField(zero bool) string {
+	if zero {
+		return ""
+	}
+	return "set"
+}
+
+func numberField(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return "set"
+}
+
+// validate checks the records of export for empty required fields when
+// opts.Strict is set. Invalid records fail the export, or are dropped and
+// counted with StrictDrop.
+func (opts Options) validate(export *Export) error {
+	if !opts.Strict {
+		return nil
+	}
+	var err error
+	if export.Commits, err = validRecords(opts, export.Commits); err != nil {
+		return err
+	}
+	if export.PullRequests, err = validRecords(opts, export.PullRequests); err != nil {
+		return err
+	}
+	if export.Issues, err = validRecords(opts, export.Issues); err != nil {
+		return err
+	}
+	if export.Releases, err = validRecords(opts, export.Releases); err != nil {
+		return err
+	}
+	if export.Watch, err = validRecords(opts, export.Watch); err != nil {
+		return err
+	}
+	if export.CheckRuns, err = validRecords(opts, export.CheckRuns); err != nil {
+		return err
+	}
+	if export.Deployments, err = validRecords(opts, export.Deployments); err != nil {
+		return err
+	}
+	if export.Timeline, err = validRecords(opts, export.Timeline); err != nil {
+		return err
+	}
+	if export.Discussions, err = validRecords(opts, export.Discussions); err != nil {
+		return err
+	}
+	if export.Collaborators, err = validRecords(opts, export.Collaborators); err != nil {
+		return err
+	}
+	export.Stargazers, err = validRecords(opts, export.Stargazers)
+	return err
+}
+
+func validRecords[T record](opts Options, records []T) ([]T, error) {
+	valid := records[:0]
+	for _, r := range records {
+		missing := missing(r)
+		if len(missing) == 0 {
+			valid = append(valid, r)
+			continue
+		}
+		if !opts.StrictDrop {
+			return nil, fmt.Errorf("strict: record %s in %s is missing %s", r.key(), r.repo(), strings.Join(missing, ", "))
+		}
+		*opts.dropped++
+		if opts.Logger != nil {
+			opts.Logger.Printf("strict: dropping record %s in %s, missing %s", r.key(), r.repo(), strings.Join(missing, ", "))
+		}
+	}
+	return valid, nil
+}
@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"sort"
+	"time"
+)
+
+// WatchPeriod is a span of time during which a repository was watched, as
+// far as the watch events of an events mode export tell. Github only sends
+// an event when a repository is watched, never when it is unwatched, so only
+// the start of a period is known. A repository watched again was unwatched
+// in between at an unknown time, which is all Ended records.
+type WatchPeriod struct {
+	Repo    string    `json:"repo"`
+	Author  string    `json:"author"`
+	Member  string    `json:"member,omitempty"`
+	Started time.Time `json:"started"`
+	// Ended is set when the repository was watched again later, so the
+	// period ended before that.
+	Ended bool `json:"ended,omitempty"`
+}
+
+// WatchHistory turns the started actions in watch into watch periods per
+// repository and member, in the order they started. Other actions, such as
+// the "watching" of a repository listing, carry no date and are left out.
+func WatchHistory(watch []Watch) []WatchPeriod {
+	sorted := make([]Watch, len(watch))
+	copy(sorted, watch)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	type watcher struct{ repo, member string }
+	latest := map[watcher]int{}
+	var history []WatchPeriod
+	for _, w := range sorted {
+		if w.Action != "started" {
+			continue
+		}
+		key := watcher{w.Repo, w.Member}
+		if i, ok := latest[key]; ok {
+			history[i].Ended = true
+		}
+		latest[key] = len(history)
+		history = append(history, WatchPeriod{Repo: w.Repo, Author: w.Author, Member: w.Member, Started: w.Date})
+	}
+	return history
+}
@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// watchEvents is a page of the events of octo as Github sends it, newest
+// first: octo watched octo/tool twice and octo/lib once, and pushed in
+// between.
+const watchEvents = `[
+  {"id": "4", "type": "WatchEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/tool"},
+   "payload": {"action": "started"}, "public": true, "created_at": "2024-06-20T09:00:00Z"},
+  {"id": "3", "type": "PushEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/lib"},
+   "payload": {"push_id": 1, "size": 0, "ref": "refs/heads/main", "commits": []}, "public": true, "created_at": "2024-06-15T09:00:00Z"},
+  {"id": "2", "type": "WatchEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/lib"},
+   "payload": {"action": "started"}, "public": true, "created_at": "2024-06-10T09:00:00Z"},
+  {"id": "1", "type": "WatchEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/tool"},
+   "payload": {"action": "started"}, "public": true, "created_at": "2024-06-01T09:00:00Z"}
+]`
+
+func TestFetchWatchHistory(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/octo/events" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, watchEvents)
+	}))
+
+	opts := Options{Author: "octo", Kind: "watched", Mode: "events", WatchHistory: true}
+	export, err := Fetch(context.Background(), client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, period := range export.WatchHistory {
+		got = append(got, fmt.Sprintf("%s %s ended=%t", period.Repo, period.Started.Format("01-02"), period.Ended))
+	}
+	want := "[octo/tool 06-01 ended=true octo/lib 06-10 ended=false octo/tool 06-20 ended=false]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("watch history %v, want %s", got, want)
+	}
+}
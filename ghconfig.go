@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultHost = "github.com"
+
+// ghHost is a host entry of the gh CLI configuration.
+type ghHost struct {
+	Name  string
+	User  string
+	Token string
+}
+
+// ghConfigPath returns the hosts.yml of the gh CLI, honoring GH_CONFIG_DIR
+// and XDG_CONFIG_HOME the way gh does.
+func ghConfigPath() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml"), nil
+}
+
+// readGHHosts reads the host entries of the gh CLI configuration in file
+// order. hosts.yml only nests host keys and their scalar settings, so it is
+// parsed line by line rather than pulling in a YAML library.
+func readGHHosts(path string) ([]ghHost, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var hosts []ghHost
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			hosts = append(hosts, ghHost{Name: key})
+		case len(hosts) == 0:
+			return nil, fmt.Errorf("%s: setting outside of a host entry", path)
+		case key == "user" && hosts[len(hosts)-1].User == "":
+			hosts[len(hosts)-1].User = value
+		case key == "oauth_token" && hosts[len(hosts)-1].Token == "":
+			// Newer gh versions also list the token per account under
+			// users:, the first one seen belongs to the active account.
+			hosts[len(hosts)-1].Token = value
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+// ghConfigHost picks the host entry to authenticate with. An explicit
+// hostname must be present in the configuration; otherwise github.com is
+// preferred and a single configured Enterprise host is used as is.
+func ghConfigHost(hosts []ghHost, hostname string) (ghHost, error) {
+	if hostname != "" {
+		for _, host := range hosts {
+			if host.Name == hostname {
+				return host, nil
+			}
+		}
+		return ghHost{}, fmt.Errorf("host %s is not configured in the gh CLI, run gh auth login --hostname %s", hostname, hostname)
+	}
+	for _, host := range hosts {
+		if host.Name == defaultHost {
+			return host, nil
+		}
+	}
+	if len(hosts) == 1 {
+		return hosts[0], nil
+	}
+	if len(hosts) == 0 {
+		return ghHost{}, fmt.Errorf("no hosts are configured in the gh CLI, run gh auth login")
+	}
+	return ghHost{}, fmt.Errorf("several hosts are configured in the gh CLI, choose one with --hostname")
+}
+
+// tokenFromGHConfig returns the token and host the gh CLI is logged in with.
+func tokenFromGHConfig(hostname string) (string, string, error) {
+	path, err := ghConfigPath()
+	if err != nil {
+		return "", "", err
+	}
+	hosts, err := readGHHosts(path)
+	if err != nil {
+		return "", "", fmt.Errorf("reading gh CLI config: %w", err)
+	}
+	host, err := ghConfigHost(hosts, hostname)
+	if err != nil {
+		return "", "", err
+	}
+	if host.Token == "" {
+		return "", "", fmt.Errorf("no token for %s in %s, gh may keep it in the system keyring, pass it with --token \"$(gh auth token)\" instead", host.Name, path)
+	}
+	return host.Token, host.Name, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// prompter asks the questions of --interactive on stderr, so they never mix
+// with an export written to stdout.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter() *prompter {
+	return &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+}
+
+// choose lists options and returns the ones picked by number. With multi
+// several can be picked, separated by commas or spaces, and an empty answer
+// picks them all; otherwise an empty answer picks def.
+func (p *prompter) choose(question string, options []string, def string, multi bool) ([]string, error) {
+	fmt.Fprintln(p.out, question)
+	for i, option := range options {
+		fmt.Fprintf(p.out, "  %2d) %s\n", i+1, option)
+	}
+	for {
+		if multi {
+			fmt.Fprint(p.out, "Numbers, separated by commas (empty for all): ")
+		} else {
+			fmt.Fprintf(p.out, "Number (empty for %s): ", def)
+		}
+		line, err := p.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, fmt.Errorf("reading answer: %w", err)
+		}
+		picked, err := pick(strings.TrimSpace(line), options, multi)
+		if err != nil {
+			fmt.Fprintln(p.out, err)
+			continue
+		}
+		switch {
+		case len(picked) > 0:
+			return picked, nil
+		case multi:
+			return options, nil
+		default:
+			return []string{def}, nil
+		}
+	}
+}
+
+// pick returns the options numbered in answer.
+func pick(answer string, options []string, multi bool) ([]string, error) {
+	fields := strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) > 1 && !multi {
+		return nil, fmt.Errorf("pick a single number")
+	}
+	var picked []string
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(options) {
+			return nil, fmt.Errorf("%q is not a number between 1 and %d", field, len(options))
+		}
+		picked = append(picked, options[n-1])
+	}
+	return picked, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// logger receives the diagnostics of every command: progress, warnings,
+// summaries and errors. It always writes to stderr, stdout only carries the
+// export itself. It logs text at the info level until setupLogging applies
+// the logging flags.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// setupLogging replaces logger with one writing in --log-format at
+// --log-level. --debug lowers the default level to debug so its request
+// traces are shown.
+func setupLogging(c *cli.Context) error {
+	level := slog.LevelInfo
+	if c.IsSet("log-level") {
+		if err := level.UnmarshalText([]byte(c.String("log-level"))); err != nil {
+			return fmt.Errorf("invalid --log-level %q, expected debug, info, warn or error", c.String("log-level"))
+		}
+	} else if c.Bool("debug") {
+		level = slog.LevelDebug
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch format := strings.ToLower(c.String("log-format")); format {
+	case "text":
+		logger = slog.New(slog.NewTextHandler(os.Stderr, options))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, options))
+	default:
+		return fmt.Errorf("invalid --log-format %q, expected text or json", format)
+	}
+	return nil
+}
+
+// logAt returns a log.Logger for the exporter package that passes every line
+// on to logger at level.
+func logAt(level slog.Level) *log.Logger {
+	return slog.NewLogLogger(logger.Handler(), level)
+}
@@ -1,110 +1,473 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
-	"encoding/csv"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
-	"text/tabwriter"
+	"syscall"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/crhuber/github-exporter/exporter"
 	"github.com/google/go-github/v64/github"
 	"github.com/urfave/cli/v2"
-	"golang.org/x/oauth2"
 )
 
-type Export struct {
-	Commits      []Commit      `json:"commits"`
-	PullRequests []PullRequest `json:"pull_requests"`
-	Issues       []Issue       `json:"issues"`
-	Releases     []Release     `json:"releases"`
-	Watch        []Watch       `json:"watch"`
-}
-
-type Commit struct {
-	Repo    string    `json:"repo"`
-	SHA     string    `json:"sha"`
-	Message string    `json:"message"`
-	Author  string    `json:"author"`
-	Date    time.Time `json:"date"`
-}
-
-type PullRequest struct {
-	Repo   string    `json:"repo"`
-	Number int       `json:"number"`
-	Title  string    `json:"title"`
-	State  string    `json:"state"`
-	Author string    `json:"author"`
-	Action string    `json:"action"`
-	Date   time.Time `json:"date"`
-}
-
-type Issue struct {
-	Repo   string    `json:"repo"`
-	Number int       `json:"number"`
-	Title  string    `json:"title"`
-	State  string    `json:"state"`
-	Author string    `json:"author"`
-	Action string    `json:"action"`
-	Date   time.Time `json:"date"`
-}
-
-type Release struct {
-	Repo    string    `json:"repo"`
-	TagName string    `json:"tag_name"`
-	Name    string    `json:"name"`
-	Author  string    `json:"author"`
-	Action  string    `json:"action"`
-	Date    time.Time `json:"date"`
-}
+var Version = "dev"
 
-type Watch struct {
-	Repo   string    `json:"repo"`
-	Author string    `json:"author"`
-	Action string    `json:"action"`
-	Date   time.Time `json:"date"`
-}
+// exitInterrupted is the exit status of an export interrupted by a signal,
+// following the shell convention of 128 + SIGINT.
+const exitInterrupted = 130
 
-var Version = "dev"
+// errInterrupted is returned by run once the partial output of an
+// interrupted export has been written.
+var errInterrupted = errors.New("export interrupted, the output only holds the records fetched so far")
 
 func main() {
 	app := &cli.App{
 		Name:  "github-export",
 		Usage: "Export GitHub user activity",
 		Flags: []cli.Flag{
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:    "output",
 				Aliases: []string{"o"},
-				Value:   "github-export.json",
-				Usage:   "Output file path",
+				Value:   cli.NewStringSlice("github-export.json"),
+				Usage:   "Output file path, or - for stdout; may hold {user}, {kind}, {format}, {date} and {org} placeholders, and end in :FORMAT to pick its format; repeat to write several",
 			},
 			&cli.StringFlag{
-				Name:     "token",
-				Aliases:  []string{"t"},
-				Usage:    "Github API access token",
-				Required: true,
-				EnvVars:  []string{"GITHUB_TOKEN"},
+				Name:  "output-dir",
+				Usage: "Directory to write output files to, created if missing",
+			},
+			&cli.BoolFlag{
+				Name:  "no-timestamp",
+				Usage: "Write to --output as given, or to a file name without the date",
+			},
+			&cli.StringFlag{
+				Name:  "env-file",
+				Value: defaultEnvFile,
+				Usage: "Load environment variables such as GITHUB_TOKEN from this file, if it exists; the environment and flags take precedence",
+			},
+			&cli.StringSliceFlag{
+				Name:    "token",
+				Aliases: []string{"t", "tokens"},
+				Usage:   "Github API access token, repeat or separate with commas to rotate through several (default: $GITHUB_TOKEN, or $GITHUB_ENTERPRISE_TOKEN with an Enterprise --hostname)",
+			},
+			&cli.StringFlag{
+				Name:    "hostname",
+				Usage:   "Github Enterprise Server host to export from (default: github.com)",
+				EnvVars: []string{"GH_HOST"},
+			},
+			&cli.BoolFlag{
+				Name:  "from-gh-config",
+				Usage: "Read the token and host from the gh CLI configuration when no token is given",
+			},
+			&cli.Int64Flag{
+				Name:  "app-id",
+				Usage: "Authenticate as this Github App instead of with a token",
+			},
+			&cli.Int64Flag{
+				Name:  "installation-id",
+				Usage: "Github App installation to authenticate as",
+			},
+			&cli.StringFlag{
+				Name:  "private-key",
+				Usage: "Path to the Github App private key (PEM)",
 			},
 			&cli.StringFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
-				Value:   "",
-				Usage:   "Output format (json, csv, txt)",
+				Value:   "table",
+				Usage:   "Output format (table, tsv, json, ndjson, csv, parquet, prom, changelog, template), several separated by commas, or all for json, csv and table",
+			},
+			&cli.BoolFlag{
+				Name:  "group-by-repo",
+				Usage: "Nest the records of the json format per repository",
+			},
+			&cli.BoolFlag{
+				Name:  "omit-empty",
+				Usage: "Leave empty optional fields out of json and ndjson records",
+			},
+			&cli.BoolFlag{
+				Name:  "relative-time",
+				Usage: "Show dates in the table format relative to now, such as 3 days ago",
+			},
+			&cli.BoolFlag{
+				Name:  "interactive",
+				Usage: "Pick the kind and repositories to export from a list, when run in a terminal",
+			},
+			&cli.StringFlag{
+				Name:  "delimiter",
+				Usage: "Field separator of the csv format, a single character such as ; or tab",
+			},
+			&cli.BoolFlag{
+				Name:  "quote-all",
+				Usage: "Quote every field of the csv format, for strict csv parsers",
+			},
+			&cli.IntFlag{
+				Name:  "short-sha",
+				Usage: "Shorten commit SHAs in the csv, tsv and table formats to this many characters, from 7 to 12",
+			},
+			&cli.BoolFlag{
+				Name:  "bom",
+				Usage: "Start csv output with a UTF-8 byte order mark, so Excel reads non-ASCII names correctly",
+			},
+			&cli.StringFlag{
+				Name:  "template-file",
+				Usage: "Go text/template executed against the export by the template format",
+			},
+			&cli.StringFlag{
+				Name:  "preset",
+				Usage: "Apply a bundle of output options (archive, report, spreadsheet), flags given explicitly take precedence",
+			},
+			&cli.StringFlag{
+				Name:  "group-by",
+				Usage: "Output record counts grouped by repo, author, member, month or day",
+			},
+			&cli.StringSliceFlag{
+				Name:  "fields-map",
+				Usage: "Rename output columns or keys, as name=new pairs separated by commas (e.g. SHA=sha,Date=created_at)",
+			},
+			&cli.BoolFlag{
+				Name:  "append",
+				Usage: "Append to the output file instead of replacing it (ndjson and csv)",
+			},
+			&cli.BoolFlag{
+				Name:  "dedupe-across-files",
+				Usage: "With --append, skip records already in the output file",
+			},
+			&cli.BoolFlag{
+				Name:  "compress",
+				Usage: "Gzip compress the output",
 			},
 			&cli.StringFlag{
 				Name:    "kind",
 				Aliases: []string{"k"},
 				Value:   "commits",
-				Usage:   "Kind of data to export (commits, pull_requests, issues, releases)",
+				Usage:   "Kind of data to export (commits, pull_requests, issues, releases, watched, checks, deployments, timeline, discussions, collaborators, stargazers)",
 			},
 			&cli.StringFlag{
 				Name:    "mode",
 				Aliases: []string{"m"},
 				Value:   "",
-				Usage:   "Use the Github events API",
+				Usage:   "Data source: events for the Github events API, search for the search API (issues and pull_requests)",
+			},
+			&cli.StringFlag{
+				Name:  "query",
+				Usage: "Raw Github search query, implies --mode search",
+			},
+			&cli.StringFlag{
+				Name:  "author",
+				Usage: "Login whose activity is exported (default: authenticated user)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "members",
+				Usage: "Export the combined activity of these logins, separated by commas",
+			},
+			&cli.StringFlag{
+				Name:  "team",
+				Usage: "Export the combined activity of the members of this team (org/slug)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "repo",
+				Usage: "Only export these repositories (owner/name, a URL, or the name of one of yours), repeat or separate with commas",
+			},
+			&cli.BoolFlag{
+				Name:  "fail-fast",
+				Usage: "Stop at the first --repo that is not found instead of skipping it",
+			},
+			&cli.IntFlag{
+				Name:  "number",
+				Usage: "Export the timeline of this issue or pull request of the single --repo",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "Only export activity on or after this date (YYYY-MM-DD)",
+			},
+			&cli.StringFlag{
+				Name:  "until",
+				Usage: "Only export activity on or before this date (YYYY-MM-DD)",
+			},
+			&cli.StringFlag{
+				Name:  "since-token",
+				Usage: "Continue after the latest record of the kind in this resume token from a previous run",
+			},
+			&cli.StringFlag{
+				Name:  "resume-token-file",
+				Usage: "Also write the resume token printed at the end of the run to this file",
+			},
+			&cli.BoolFlag{
+				Name:  "with-commits",
+				Usage: "Include the commits of each pull request (one extra request per pull request)",
+			},
+			&cli.BoolFlag{
+				Name:  "with-committer",
+				Usage: "Add the author login, committer name and committer login columns of commits to csv output",
+			},
+			&cli.BoolFlag{
+				Name:  "with-patch",
+				Usage: "Include the changed files and patches of each commit (one extra request per commit)",
+			},
+			&cli.StringFlag{
+				Name:  "patch-dir",
+				Usage: "Write commit patches to this directory instead of inlining them",
+			},
+			&cli.StringFlag{
+				Name:  "download-assets",
+				Usage: "Download the assets of exported releases to this directory (one extra request per asset)",
+			},
+			&cli.BoolFlag{
+				Name:  "with-body",
+				Usage: "Include the body of issues and pull requests, and of commits with --first-line-only",
+			},
+			&cli.BoolFlag{
+				Name:  "first-line-only",
+				Usage: "Keep only the subject line of commit messages",
+			},
+			&cli.BoolFlag{
+				Name:  "since-last-release",
+				Usage: "Export the commits or merged pull requests of each repository since its latest release, for release notes",
+			},
+			&cli.BoolFlag{
+				Name:  "normalize-emails",
+				Usage: "Replace Github noreply commit emails with the login they belong to",
+			},
+			&cli.BoolFlag{
+				Name:  "co-authors",
+				Usage: "Parse the Co-authored-by trailers of commit messages",
+			},
+			&cli.BoolFlag{
+				Name:  "merged-only",
+				Usage: "Only export merged pull requests, with --since and --until applied to the merge date",
+			},
+			&cli.BoolFlag{
+				Name:  "watch-history",
+				Usage: "With --kind watched in events mode, list when each repository was started to be watched in watch_history (json)",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-drafts",
+				Usage: "Skip draft pull requests and releases",
+			},
+			&cli.BoolFlag{
+				Name:  "drafts-only",
+				Usage: "Only export draft pull requests and releases",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-prereleases",
+				Usage: "Skip releases marked as prereleases",
+			},
+			&cli.StringFlag{
+				Name:  "branch",
+				Usage: "With --repo, export the commits of this branch or ref instead of the default branch",
+			},
+			&cli.StringFlag{
+				Name:  "path",
+				Usage: "Only export commits touching this file or directory",
+			},
+			&cli.BoolFlag{
+				Name:  "only-mine",
+				Usage: "When walking repositories, only export the pull requests and issues opened by the exported login instead of everyone's",
+			},
+			&cli.StringSliceFlag{
+				Name:  "label",
+				Usage: "Only export the pull requests and issues carrying this label, repeat to require several",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-merges",
+				Usage: "Skip merge commits, those with more than one parent (not supported in events mode)",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-bots",
+				Usage: "Skip records authored by bots (logins ending in [bot] and those in --bots)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "bots",
+				Usage: "Additional logins treated as bots by --exclude-bots, separated by commas",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Check records for empty required fields, such as a missing author",
+			},
+			&cli.StringFlag{
+				Name:  "strict-mode",
+				Value: "fail",
+				Usage: "What --strict does with an invalid record: fail the export, or drop the record",
+			},
+			&cli.BoolFlag{
+				Name:  "anonymize",
+				Usage: "Replace logins, names, emails, team slugs and repository owners with pseudonyms",
+			},
+			&cli.StringFlag{
+				Name:  "anonymize-map",
+				Usage: "With --anonymize, write the pseudonym to identity mapping to this JSON file",
+			},
+			&cli.StringFlag{
+				Name:    "anonymize-salt",
+				Usage:   "With --anonymize, key the pseudonyms with this secret so they stay the same across exports (default: random per run)",
+				EnvVars: []string{"GITHUB_EXPORTER_ANONYMIZE_SALT"},
+			},
+			&cli.IntFlag{
+				Name:  "min-stars",
+				Usage: "Only export repositories with at least this many stars",
+			},
+			&cli.StringFlag{
+				Name:  "pushed-since",
+				Usage: "Only export repositories pushed to since this date (YYYY-MM-DD)",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-forks",
+				Usage: "Skip forked repositories",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-archived",
+				Usage: "Skip archived repositories",
+			},
+			&cli.BoolFlag{
+				Name:  "with-repos",
+				Usage: "Add the walked repositories and their default branch to the json output",
+			},
+			&cli.BoolFlag{
+				Name:  "head-sha",
+				Usage: "With --with-repos, also record the HEAD commit of each default branch (one extra request per repository)",
+			},
+			&cli.StringFlag{
+				Name:  "repo-cache",
+				Usage: "Cache the repository list in this file between runs",
+			},
+			&cli.DurationFlag{
+				Name:  "repo-cache-ttl",
+				Value: 24 * time.Hour,
+				Usage: "How long the cached repository list stays valid",
+			},
+			&cli.BoolFlag{
+				Name:  "refresh",
+				Usage: "Ignore the cached repository list and fetch it again",
+			},
+			&cli.StringFlag{
+				Name:  "etag-cache",
+				Usage: "Store response ETags in this file and make repeated requests conditional",
+			},
+			&cli.StringFlag{
+				Name:    "cache-dir",
+				Usage:   "Cache responses in this directory following their Cache-Control headers",
+				EnvVars: []string{"GITHUB_EXPORTER_CACHE_DIR"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-cache",
+				Usage: "Bypass --cache-dir and --etag-cache for this run",
+			},
+			&cli.BoolFlag{
+				Name:  "retry-on-empty",
+				Usage: "Fetch again when the export comes back without any records",
+			},
+			&cli.IntFlag{
+				Name:  "empty-retry-attempts",
+				Value: 1,
+				Usage: "How often --retry-on-empty fetches again",
+			},
+			&cli.DurationFlag{
+				Name:  "empty-retry-delay",
+				Value: 10 * time.Second,
+				Usage: "How long --retry-on-empty waits before fetching again",
+			},
+			&cli.IntFlag{
+				Name:    "per-page",
+				Value:   100,
+				Usage:   "Number of items requested per page, from 1 to 100",
+				EnvVars: []string{"GITHUB_EXPORTER_PER_PAGE"},
+			},
+			&cli.IntFlag{
+				Name:  "max-pages",
+				Usage: "Stop every paginated listing after this many pages, 0 for no limit",
+			},
+			&cli.IntFlag{
+				Name:  "sample",
+				Usage: "Keep a uniform random sample of this many records instead of all of them",
+			},
+			&cli.Int64Flag{
+				Name:  "sample-seed",
+				Usage: "Seed of --sample, to draw the same sample again (default: random)",
+			},
+			&cli.IntFlag{
+				Name:    "max-retries",
+				Value:   3,
+				Usage:   "Maximum retries for server errors, network errors and rate limits, per request",
+				EnvVars: []string{"GITHUB_EXPORTER_MAX_RETRIES"},
+			},
+			&cli.DurationFlag{
+				Name:    "max-wait",
+				Value:   time.Hour,
+				Usage:   "Fail instead of retrying when a rate limit reset is further away than this, 0 to always wait",
+				EnvVars: []string{"GITHUB_EXPORTER_MAX_WAIT"},
+			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				Value:   time.Minute,
+				Usage:   "Fail and retry an API request that takes longer than this, 0 for no limit",
+				EnvVars: []string{"GITHUB_EXPORTER_TIMEOUT"},
+			},
+			&cli.BoolFlag{
+				Name:  "insecure-skip-verify",
+				Usage: "Skip TLS certificate verification (for self-signed Enterprise certificates)",
+			},
+			&cli.BoolFlag{
+				Name:  "verbose",
+				Usage: "Log progress and retries to stderr",
 			},
+			&cli.BoolFlag{
+				Name:  "error-if-empty",
+				Usage: "Exit with an error when the export holds no records, for use as a liveness check",
+			},
+			&cli.BoolFlag{
+				Name:  "debug",
+				Usage: "Log every Github API request and response to stderr, and report the pages and records of every listing",
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Value: "text",
+				Usage: "Format of the logs on stderr: text or json",
+			},
+			&cli.StringFlag{
+				Name:  "log-level",
+				Usage: "Least severe level logged: debug, info, warn or error (default: info, debug with --debug)",
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:   "schema",
+				Usage:  "Print the JSON Schema of the json output format",
+				Action: printSchema,
+			},
+			{
+				Name:   "list-repos",
+				Usage:  "Print the repositories an export would walk under the repository flags (json, csv or table)",
+				Action: listRepos,
+			},
+			{
+				Name:   "doctor",
+				Usage:  "Check the token, its scopes, the rate limit, the API host and the output directories before an export",
+				Action: doctor,
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if err := loadEnvFile(c); err != nil {
+				return err
+			}
+			return setupLogging(c)
 		},
 		Action: run,
 	}
@@ -114,374 +477,1216 @@ func main() {
 
 	err := app.Run(os.Args)
 	if err != nil {
-		fmt.Println("Error:", err)
+		logger.Error(err.Error())
+		if errors.Is(err, errInterrupted) {
+			os.Exit(exitInterrupted)
+		}
 		os.Exit(1)
 	}
 }
 
 func run(c *cli.Context) error {
-	token := c.String("token")
-	outputFile := c.String("output")
-	format := c.String("format")
-	kind := c.String("kind")
+	if c.IsSet("preset") {
+		if err := applyPreset(c, c.String("preset")); err != nil {
+			return err
+		}
+	}
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	host := c.String("hostname")
+	var tokens []string
+	outputs, err := parseOutputs(c.StringSlice("output"), parseFormats(c.String("format")))
+	if err != nil {
+		return err
+	}
+	formats := make([]string, len(outputs))
+	for i, output := range outputs {
+		formats[i] = output.format
+	}
+	kind := c.String("kind")
 
-	var export Export
-	var err error
-	if c.String("mode") == "events" {
-		export, err = fetchGitHubEvents(ctx, client)
+	// The prompts are skipped when there is nobody to answer them, such as
+	// in CI or with stdin redirected.
+	var prompts *prompter
+	if c.Bool("interactive") {
+		if isTerminal(os.Stdin) {
+			prompts = newPrompter()
+		} else {
+			logger.Warn("--interactive needs a terminal, continuing without prompts")
+		}
+	}
+	if prompts != nil && !c.IsSet("kind") && !c.IsSet("number") {
+		picked, err := prompts.choose("Kind of data to export:", exporter.Kinds, kind, false)
 		if err != nil {
 			return err
 		}
+		kind = picked[0]
+	}
+
+	teamFlags := 0
+	for _, name := range []string{"author", "members", "team"} {
+		if c.IsSet(name) {
+			teamFlags++
+		}
+	}
+	if teamFlags > 1 {
+		return fmt.Errorf("only one of --author, --members and --team can be given")
+	}
+
+	if c.IsSet("app-id") {
+		if !c.IsSet("installation-id") || c.String("private-key") == "" {
+			return fmt.Errorf("--app-id requires --installation-id and --private-key")
+		}
+		if c.String("author") == "" && !c.IsSet("members") && !c.IsSet("team") {
+			return fmt.Errorf("--app-id requires --author, --members or --team, a Github App has no user of its own")
+		}
 	} else {
-		export, err = fetchGitHubData(ctx, client, kind)
+		var err error
+		if tokens, host, err = resolveTokens(c, host); err != nil {
+			return err
+		}
+	}
+
+	for _, format := range formats {
+		if !exporter.ValidFormat(format) {
+			return fmt.Errorf("unsupported format: %s", format)
+		}
+	}
+
+	if c.Bool("exclude-merges") && (kind != "commits" || c.String("mode") == "events") {
+		return fmt.Errorf("--exclude-merges is only supported for the commits kind outside of events mode")
+	}
+	if c.Bool("only-mine") && kind != "pull_requests" && kind != "issues" {
+		return fmt.Errorf("--only-mine is only supported for the pull_requests and issues kinds")
+	}
+	if c.IsSet("label") && kind != "pull_requests" && kind != "issues" {
+		return fmt.Errorf("--label is only supported for the pull_requests and issues kinds")
+	}
+	if c.Bool("exclude-prereleases") && kind != "releases" {
+		return fmt.Errorf("--exclude-prereleases is only supported for the releases kind")
+	}
+	if c.Bool("exclude-drafts") && c.Bool("drafts-only") {
+		return fmt.Errorf("--exclude-drafts and --drafts-only cannot be combined")
+	}
+	if c.Bool("merged-only") && kind != "pull_requests" {
+		return fmt.Errorf("--merged-only is only supported for the pull_requests kind")
+	}
+
+	mode := c.String("mode")
+	if c.IsSet("number") {
+		if c.IsSet("kind") && kind != "timeline" {
+			return fmt.Errorf("--number exports a timeline and cannot be combined with --kind %s", kind)
+		}
+		kind = "timeline"
+	}
+	if kind == "timeline" {
+		if !c.IsSet("number") || len(c.StringSlice("repo")) != 1 {
+			return fmt.Errorf("the timeline kind requires --number and a single --repo")
+		}
+		if mode != "" || c.IsSet("members") || c.IsSet("team") {
+			return fmt.Errorf("the timeline kind cannot be combined with --mode, --members or --team")
+		}
+	}
+
+	if c.String("query") != "" {
+		if mode != "" && mode != "search" {
+			return fmt.Errorf("--query requires --mode search")
+		}
+		mode = "search"
+		// These are either part of the generated query or only apply when
+		// walking repositories, so they cannot be honored with a raw query.
+		for _, name := range []string{"author", "members", "team", "repo", "label", "since", "since-token", "until", "min-stars", "pushed-since", "exclude-forks", "exclude-archived", "repo-cache"} {
+			if c.IsSet(name) {
+				return fmt.Errorf("--query cannot be combined with --%s, express it in the query instead", name)
+			}
+		}
+	}
+
+	if c.Bool("head-sha") && !c.Bool("with-repos") {
+		return fmt.Errorf("--head-sha requires --with-repos")
+	}
+
+	if perPage := c.Int("per-page"); perPage < 1 || perPage > 100 {
+		return fmt.Errorf("--per-page must be between 1 and 100")
+	}
 
+	strictMode := c.String("strict-mode")
+	if strictMode != "fail" && strictMode != "drop" {
+		return fmt.Errorf("unsupported --strict-mode: %s", strictMode)
+	}
+
+	groupBy := c.String("group-by")
+	if groupBy != "" && !slices.Contains(exporter.GroupByFields, groupBy) {
+		return fmt.Errorf("unsupported group by field: %s", groupBy)
+	}
+
+	if c.Bool("group-by-repo") {
+		if !slices.Contains(formats, "json") {
+			return fmt.Errorf("--group-by-repo is only supported for the json format")
+		}
+		if groupBy != "" {
+			return fmt.Errorf("--group-by-repo cannot be combined with --group-by")
+		}
+	}
+
+	if slices.Contains(formats, "changelog") {
+		if !exporter.ChangelogKind(kind) {
+			return fmt.Errorf("the changelog format only supports the commits and pull_requests kinds")
+		}
+		if groupBy != "" {
+			return fmt.Errorf("the changelog format cannot be combined with --group-by")
+		}
+	}
+
+	if slices.Contains(formats, "parquet") {
+		if groupBy != "" {
+			return fmt.Errorf("the parquet format cannot be combined with --group-by")
+		}
+		// Parquet compresses its columns itself, and readers cannot open a
+		// gzipped file.
+		if c.Bool("compress") {
+			return fmt.Errorf("--compress does not apply to the parquet format, which is compressed with Snappy")
+		}
+	}
+
+	var tmpl *template.Template
+	if slices.Contains(formats, "template") {
+		path := c.String("template-file")
+		if path == "" {
+			return fmt.Errorf("the template format requires --template-file")
+		}
+		if groupBy != "" {
+			return fmt.Errorf("the template format cannot be combined with --group-by")
+		}
+		text, err := os.ReadFile(path)
 		if err != nil {
+			return fmt.Errorf("reading --template-file: %w", err)
+		}
+		if tmpl, err = exporter.ParseTemplate(filepath.Base(path), string(text)); err != nil {
+			return fmt.Errorf("invalid --template-file: %w", err)
+		}
+	} else if c.IsSet("template-file") {
+		return fmt.Errorf("--template-file requires --format template")
+	}
+
+	var delimiter rune
+	if c.IsSet("delimiter") {
+		if !slices.Contains(formats, "csv") {
+			return fmt.Errorf("--delimiter only applies to the csv format")
+		}
+		var err error
+		if delimiter, err = parseDelimiter(c.String("delimiter")); err != nil {
 			return err
 		}
 	}
 
-	outputFile = generateFilePath(outputFile, kind, format)
+	if c.IsSet("short-sha") {
+		if n := c.Int("short-sha"); n < 7 || n > 12 {
+			return fmt.Errorf("invalid --short-sha %d, expected a length from 7 to 12", n)
+		}
+	}
 
-	switch format {
-	case "json":
+	if c.Bool("quote-all") && !slices.Contains(formats, "csv") {
+		return fmt.Errorf("--quote-all only applies to the csv format")
+	}
 
-		err = outputJSON(export, outputFile)
-	case "csv":
-		err = outputCSV(export, outputFile, kind)
-	default:
-		err = outputStdOut(export, kind)
+	if c.Bool("bom") && !slices.Contains(formats, "csv") {
+		return fmt.Errorf("--bom only applies to the csv format")
 	}
 
+	fields, err := parseFieldMap(c.StringSlice("fields-map"))
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid --fields-map: %w", err)
 	}
 
-	fmt.Printf("Export completed successfully. Output written to %s\n", outputFile)
-	return nil
-}
+	if c.Bool("with-commits") {
+		if kind != "pull_requests" {
+			return fmt.Errorf("--with-commits is only supported for the pull_requests kind")
+		}
+		logger.Warn("--with-commits lists the commits of every pull request individually, this is slow and uses a lot of rate limit")
+	}
+	if c.IsSet("download-assets") && kind != "releases" {
+		return fmt.Errorf("--download-assets is only supported for the releases kind")
+	}
+	if c.Bool("with-committer") && kind != "commits" {
+		return fmt.Errorf("--with-committer is only supported for the commits kind")
+	}
+	if c.Bool("with-patch") {
+		if kind != "commits" || mode == "events" || mode == "search" {
+			return fmt.Errorf("--with-patch is only supported for the commits kind")
+		}
+		logger.Warn("--with-patch fetches every commit individually, this is slow and uses a lot of rate limit")
+	}
 
-func fetchGitHubData(ctx context.Context, client *github.Client, kind string) (Export, error) {
-	export := Export{}
+	if kind == "checks" {
+		if mode == "events" || mode == "search" {
+			return fmt.Errorf("the checks kind is only supported when walking repositories")
+		}
+		logger.Warn("the checks kind lists the check runs of every commit individually, this is slow and uses a lot of rate limit")
+	}
+	if (kind == "deployments" || kind == "discussions" || kind == "collaborators" || kind == "stargazers") && (mode == "events" || mode == "search") {
+		return fmt.Errorf("the %s kind is only supported when walking repositories", kind)
+	}
+	if c.Bool("watch-history") {
+		if kind != "watched" || mode != "events" {
+			return fmt.Errorf("--watch-history is only supported for the watched kind in events mode")
+		}
+		if !slices.Contains(formats, "json") {
+			return fmt.Errorf("--watch-history is only written by the json format")
+		}
+	}
+	if c.IsSet("sample") {
+		if c.Int("sample") < 1 {
+			return fmt.Errorf("--sample must be at least 1")
+		}
+		if c.Bool("append") || c.Bool("watch-history") {
+			return fmt.Errorf("--sample cannot be combined with --append or --watch-history")
+		}
+	} else if c.IsSet("sample-seed") {
+		return fmt.Errorf("--sample-seed requires --sample")
+	}
+	if c.IsSet("branch") {
+		if (kind != "commits" && kind != "checks") || mode == "events" || mode == "search" {
+			return fmt.Errorf("--branch is only supported for the commits and checks kinds when walking repositories")
+		}
+		if !c.IsSet("repo") {
+			return fmt.Errorf("--branch requires --repo, other repositories are unlikely to have the branch")
+		}
+	}
+	if c.IsSet("path") && ((kind != "commits" && kind != "checks") || mode == "events" || mode == "search") {
+		return fmt.Errorf("--path is only supported for the commits and checks kinds when walking repositories")
+	}
+	if c.Bool("since-last-release") {
+		if !exporter.ChangelogKind(kind) || mode == "events" || mode == "search" {
+			return fmt.Errorf("--since-last-release is only supported for the commits and pull_requests kinds when walking repositories")
+		}
+		if c.IsSet("since") {
+			return fmt.Errorf("--since-last-release cannot be combined with --since")
+		}
+	}
+	if c.IsSet("since-token") && (c.IsSet("since") || c.Bool("since-last-release")) {
+		return fmt.Errorf("--since-token cannot be combined with --since or --since-last-release")
+	}
 
-	// List user's repositories
-	opt := &github.RepositoryListByAuthenticatedUserOptions{
-		ListOptions: github.ListOptions{PerPage: 100},
-		Affiliation: "owner",
+	pushedSince, err := parseDate(c.String("pushed-since"))
+	if err != nil {
+		return fmt.Errorf("invalid --pushed-since: %w", err)
 	}
-	// Fetch repositories
-	repos, _, err := client.Repositories.ListByAuthenticatedUser(ctx, opt)
+	since, err := parseDate(c.String("since"))
 	if err != nil {
-		return export, err
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	// The new resume token keeps the dates of the other kinds of the one
+	// given, so several kinds can be chained through a single token.
+	resume := exporter.ResumeToken{}
+	if value := c.String("since-token"); value != "" {
+		if resume, err = exporter.ParseResumeToken(value); err != nil {
+			return fmt.Errorf("invalid --since-token: %w", err)
+		}
+		since = resume.Since(kind)
+	}
+	until, err := parseDate(c.String("until"))
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	if !until.IsZero() {
+		// Include the whole of the --until day.
+		until = until.Add(24*time.Hour - time.Nanosecond)
 	}
 
-	// Get authenticated user
-	user, _, err := client.Users.Get(ctx, "")
+	// SIGINT and SIGTERM cancel the export, after which the records
+	// fetched so far are still written out.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	rates := &exporter.RateTracker{}
+	client, auth, etags, err := newClient(c, host, tokens, rates)
 	if err != nil {
-		return export, err
+		return err
 	}
-	username := user.GetLogin()
+	storage := storageClient(c, rates)
 
-	for _, repo := range repos {
-		opt := &github.CommitsListOptions{
-			Author:      username,
-			ListOptions: github.ListOptions{PerPage: 100},
+	opts := exporter.Options{
+		Kind:               kind,
+		Mode:               mode,
+		Author:             c.String("author"),
+		Members:            c.StringSlice("members"),
+		Repos:              c.StringSlice("repo"),
+		FailFast:           c.Bool("fail-fast"),
+		Number:             c.Int("number"),
+		WithRepos:          c.Bool("with-repos"),
+		WithHeadSHA:        c.Bool("head-sha"),
+		InstallationRepos:  c.IsSet("app-id"),
+		Query:              c.String("query"),
+		WithPatch:          c.Bool("with-patch"),
+		WithCommits:        c.Bool("with-commits"),
+		WithBody:           c.Bool("with-body"),
+		WithCoAuthors:      c.Bool("co-authors"),
+		FirstLineOnly:      c.Bool("first-line-only"),
+		NormalizeEmails:    c.Bool("normalize-emails"),
+		SinceLastRelease:   c.Bool("since-last-release"),
+		Branch:             c.String("branch"),
+		Path:               c.String("path"),
+		MergedOnly:         c.Bool("merged-only"),
+		WatchHistory:       c.Bool("watch-history"),
+		ExcludeDrafts:      c.Bool("exclude-drafts"),
+		ExcludeMerges:      c.Bool("exclude-merges"),
+		OnlyMine:           c.Bool("only-mine"),
+		Labels:             c.StringSlice("label"),
+		DraftsOnly:         c.Bool("drafts-only"),
+		ExcludePrereleases: c.Bool("exclude-prereleases"),
+		Strict:             c.Bool("strict"),
+		StrictDrop:         strictMode == "drop",
+		ExcludeBots:        c.Bool("exclude-bots"),
+		Bots:               c.StringSlice("bots"),
+		Since:              since,
+		Until:              until,
+		MinStars:           c.Int("min-stars"),
+		PushedSince:        pushedSince,
+		ExcludeForks:       c.Bool("exclude-forks"),
+		ExcludeArchived:    c.Bool("exclude-archived"),
+		RepoCache:          c.String("repo-cache"),
+		RepoCacheTTL:       c.Duration("repo-cache-ttl"),
+		RefreshRepoCache:   c.Bool("refresh"),
+		EmptyRetryDelay:    c.Duration("empty-retry-delay"),
+		PerPage:            c.Int("per-page"),
+		MaxPages:           c.Int("max-pages"),
+	}
+	if c.Bool("verbose") {
+		opts.Logger = logAt(slog.LevelInfo)
+	}
+	opts.Warnings = logAt(slog.LevelWarn)
+	// --error-if-empty tells an account without activity from filters that
+	// excluded everything by the records Github returned.
+	if c.Bool("debug") || c.Bool("error-if-empty") {
+		opts.Stats = &exporter.Stats{}
+	}
+
+	if team := c.String("team"); team != "" {
+		org, slug, ok := strings.Cut(team, "/")
+		if !ok || org == "" || slug == "" {
+			return fmt.Errorf("invalid --team %q, expected org/slug", team)
+		}
+		if opts.Members, err = exporter.TeamMembers(ctx, client, org, slug, opts); err != nil {
+			return fmt.Errorf("listing members of %s: %w", team, err)
+		}
+		if len(opts.Members) == 0 {
+			return fmt.Errorf("team %s has no members", team)
+		}
+	}
+
+	// The login is needed throughout the export, look it up only once.
+	if opts.Author == "" && len(opts.Members) == 0 {
+		if opts.Author, err = exporter.Login(ctx, client); err != nil {
+			return err
+		}
+	}
+	if c.Bool("anonymize") {
+		if opts.Anonymizer, err = exporter.NewAnonymizer(c.String("anonymize-salt")); err != nil {
+			return err
+		}
+	} else if c.IsSet("anonymize-map") {
+		return fmt.Errorf("--anonymize-map requires --anonymize")
+	} else if c.IsSet("anonymize-salt") {
+		return fmt.Errorf("--anonymize-salt requires --anonymize")
+	}
+	if c.Bool("retry-on-empty") {
+		opts.EmptyRetries = c.Int("empty-retry-attempts")
+	}
+	if prompts != nil && mode == "" && len(opts.Repos) == 0 && kind != "watched" {
+		repos, err := exporter.Repositories(ctx, client, opts)
+		if err != nil {
+			return err
 		}
+		names := make([]string, len(repos))
+		for i, repo := range repos {
+			names[i] = repo.GetFullName()
+		}
+		picked, err := prompts.choose("Repositories to export:", names, "", true)
+		if err != nil {
+			return err
+		}
+		// Picking them all is the same as picking none.
+		if len(picked) < len(names) {
+			opts.Repos = picked
+		}
+	}
+
+	writeOpts := exporter.WriteOptions{
+		Format:        formats[0],
+		Kind:          kind,
+		WithBody:      c.Bool("with-body"),
+		WithMember:    len(opts.Members) > 0,
+		WithCommits:   c.Bool("with-commits"),
+		WithCommitter: c.Bool("with-committer"),
+		GroupBy:       groupBy,
+		Fields:        fields,
+
+		OmitEmpty:    c.Bool("omit-empty"),
+		NestByRepo:   c.Bool("group-by-repo"),
+		RelativeTime: c.Bool("relative-time"),
+		Template:     tmpl,
+		Delimiter:    delimiter,
+		ShortSHA:     c.Int("short-sha"),
+		QuoteAll:     c.Bool("quote-all"),
+		BOM:          c.Bool("bom"),
+	}
+
+	// {org} is the organization of --team, or the owner shared by every
+	// --repo. {user} is empty for team exports, which have no single login.
+	vars := map[string]string{
+		"user": opts.Author,
+		"kind": kind,
+		"date": time.Now().Format("20060102"),
+		"org":  outputOrg(c),
+	}
+	compress := c.Bool("compress")
+	outputFiles := make([]string, len(outputs))
+	for i, output := range outputs {
+		if outputFiles[i], err = outputPath(c, output, kind, compress, vars); err != nil {
+			return err
+		}
+		if outputFiles[i] != "-" && slices.Contains(outputFiles[:i], outputFiles[i]) {
+			return fmt.Errorf("several formats would be written to %s, use --output-dir instead of --output", outputFiles[i])
+		}
+	}
+	outputFile := outputFiles[0]
 
-		switch kind {
-		case "commits":
-			// Fetch commits
-			commits, _, err := client.Repositories.ListCommits(ctx, *repo.Owner.Login, *repo.Name, opt)
+	// A single ndjson or csv output is written as the records are fetched,
+	// so the export is never held in memory as a whole. A sample is only
+	// known once every record has been fetched.
+	streaming := len(formats) == 1 && exporter.Streams(writeOpts) && !c.IsSet("sample")
+
+	var keys exporter.KeySet
+	if c.Bool("append") {
+		if !streaming || outputFile == "-" {
+			return fmt.Errorf("--append requires an ndjson or csv output file")
+		}
+		if info, err := os.Stat(outputFile); err == nil && info.Size() > 0 {
+			writeOpts.Append = true
+			err := readOutput(outputFile, compress, func(r io.Reader) error { return exporter.CheckAppend(r, writeOpts) })
 			if err != nil {
-				return export, err
+				return fmt.Errorf("appending to %s: %w", outputFile, err)
 			}
-			for _, commit := range commits {
-				export.Commits = append(export.Commits, Commit{
-					Repo:    *repo.Name,
-					SHA:     *commit.SHA,
-					Message: *commit.Commit.Message,
-					Author:  *commit.Commit.Author.Name,
-					Date:    commit.Commit.Author.Date.Time,
-				})
+		}
+		if c.Bool("dedupe-across-files") {
+			if c.IsSet("short-sha") && slices.Contains(formats, "csv") {
+				return fmt.Errorf("--dedupe-across-files cannot be combined with --short-sha for the csv format, whose shortened SHAs cannot be matched")
 			}
-		case "pull_requests":
-
-			// Fetch pull requests
-			prs, _, err := client.PullRequests.List(ctx, *repo.Owner.Login, *repo.Name, nil)
-			if err != nil {
-				return export, err
+			if keys, err = loadKeys(outputFile, writeOpts, compress); err != nil {
+				return fmt.Errorf("reading %s: %w", outputFile, err)
 			}
-			for _, pr := range prs {
-				export.PullRequests = append(export.PullRequests, PullRequest{
-					Repo:   *repo.Name,
-					Number: *pr.Number,
-					Title:  *pr.Title,
-					State:  *pr.State,
-					Author: *pr.User.Login,
-					Date:   pr.CreatedAt.Time,
-				})
+		}
+	} else if c.Bool("dedupe-across-files") {
+		return fmt.Errorf("--dedupe-across-files requires --append")
+	}
+
+	var closeOutput func() error
+	duplicates, streamed := 0, 0
+	if streaming {
+		var w io.Writer
+		w, closeOutput, err = openOutput(outputFile, compress, c.Bool("append"))
+		if err != nil {
+			return err
+		}
+		stream, err := exporter.NewStreamWriter(w, writeOpts)
+		if err != nil {
+			closeOutput()
+			return err
+		}
+		patchDir, assetDir := c.String("patch-dir"), c.String("download-assets")
+		opts.Stream = func(batch exporter.Export) error {
+			if keys != nil {
+				duplicates += keys.Dedupe(&batch)
 			}
-		case "issues":
-			// Fetch issues
-			issues, _, err := client.Issues.ListByRepo(ctx, *repo.Owner.Login, *repo.Name, nil)
-			if err != nil {
-				return export, err
+			if patchDir != "" {
+				if err := exporter.WritePatches(&batch, patchDir); err != nil {
+					return err
+				}
 			}
-			for _, issue := range issues {
-				if issue.PullRequestLinks == nil {
-					export.Issues = append(export.Issues, Issue{
-						Repo:   *repo.Name,
-						Number: *issue.Number,
-						Title:  *issue.Title,
-						State:  *issue.State,
-						Author: *issue.User.Login,
-						Date:   issue.CreatedAt.Time,
-					})
+			if assetDir != "" {
+				if err := exporter.DownloadAssets(ctx, client, storage, &batch, assetDir); err != nil {
+					return err
 				}
 			}
+			streamed += exporter.Count(batch, kind)
+			resume.Observe(batch, kind)
+			return stream.Write(batch)
+		}
+	}
 
-		case "releases":
-			// Fetch releases
-			releases, _, err := client.Repositories.ListReleases(ctx, *repo.Owner.Login, *repo.Name, nil)
-			if err != nil {
-				return export, err
-			}
-			for _, release := range releases {
-				export.Releases = append(export.Releases, Release{
-					Repo:    *repo.Name,
-					TagName: *release.TagName,
-					Name:    *release.Name,
-					Author:  *release.Author.Login,
-					Date:    release.CreatedAt.Time,
-				})
-			}
-		default:
-			return export, fmt.Errorf("unsupported kind: %s", kind)
+	// The sample is drawn while fetching, so only the sampled records are
+	// held in memory.
+	var sampler *exporter.Sampler
+	seed := c.Int64("sample-seed")
+	if c.IsSet("sample") {
+		if !c.IsSet("sample-seed") {
+			seed = time.Now().UnixNano()
+		}
+		sampler = exporter.NewSampler(kind, c.Int("sample"), seed)
+		opts.Stream = func(batch exporter.Export) error {
+			resume.Observe(batch, kind)
+			return sampler.Add(batch)
 		}
 	}
-	return export, nil
-}
 
-func outputJSON(export Export, outputFile string) error {
-	data, err := json.MarshalIndent(export, "", "  ")
+	export, err := exporter.Fetch(ctx, client, opts)
+	if sampler != nil {
+		sampler.Sample(&export)
+		logger.Info("sampled records", "kept", exporter.Count(export, kind), "seen", sampler.Seen(), "sample_seed", seed)
+	}
+	interrupted := err != nil && ctx.Err() != nil
+	if interrupted {
+		// A second signal terminates right away.
+		stop()
+		logger.Warn("interrupted, writing the records fetched so far")
+		export.Meta.Partial = true
+		err = nil
+	}
+	if closeOutput != nil {
+		if cerr := closeOutput(); err == nil {
+			err = cerr
+		}
+	}
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(outputFile, data, 0644)
-}
 
-func outputCSV(export Export, outputFile string, kind string) error {
-	file, err := os.Create(outputFile)
-	if err != nil {
-		return err
+	// The stats are only written out with --debug.
+	stats := export.Stats
+	if !c.Bool("debug") {
+		export.Stats = nil
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	if export.Meta.Dropped > 0 {
+		logger.Info("dropped records with empty required fields", "records", export.Meta.Dropped)
+	}
 
-	// Write headers
-	headers := []string{"Type", "Repo", "ID", "Title", "State", "Author", "Date"}
-	if err := writer.Write(headers); err != nil {
-		return err
+	if keys != nil {
+		logger.Info("skipped records already in the output", "records", duplicates, "output", outputFile)
 	}
 
-	switch kind {
-	case "commits":
-		// Write commits
-		for _, commit := range export.Commits {
-			row := []string{"Commit", commit.Repo, commit.SHA, commit.Message, "", commit.Author, commit.Date.String()}
-			if err := writer.Write(row); err != nil {
-				return err
-			}
+	// The json format carries the stats itself.
+	if export.Stats != nil && !slices.Contains(formats, "json") {
+		for _, listing := range export.Stats.Listings {
+			logger.Debug("listing done", "repo", listing.Repo, "listing", listing.Listing,
+				"pages", listing.Pages, "records", listing.Records, "capped", listing.Capped)
 		}
-	case "pull_requests":
-		// Write pull requests
-		for _, pr := range export.PullRequests {
-			row := []string{"PullRequest", pr.Repo, fmt.Sprintf("%d", pr.Number), pr.Title, pr.State, pr.Author, pr.Date.String()}
-			if err := writer.Write(row); err != nil {
-				return err
-			}
+	}
+
+	if c.Bool("verbose") && auth != nil {
+		for i, remaining := range auth.Remaining() {
+			logger.Info("token rate limit", "token", i+1, "remaining", remaining)
 		}
-	case "issues":
+	}
+
+	export.Meta.Version = Version
+	export.Meta.Flags = setFlags(c)
+	export.Meta.RateLimit = rates.Usage()
+	resources := make([]string, 0, len(export.Meta.RateLimit))
+	for resource := range export.Meta.RateLimit {
+		resources = append(resources, resource)
+	}
+	slices.Sort(resources)
+	for _, resource := range resources {
+		usage := export.Meta.RateLimit[resource]
+		logger.Info("rate limit usage", "resource", resource, "used", usage.Used, "remaining", usage.EndRemaining)
+	}
 
-		// Write issues
-		for _, issue := range export.Issues {
-			row := []string{"Issue", issue.Repo, fmt.Sprintf("%d", issue.Number), issue.Title, issue.State, issue.Author, issue.Date.String()}
-			if err := writer.Write(row); err != nil {
+	if path := c.String("anonymize-map"); path != "" {
+		if err := writeJSONFile(path, opts.Anonymizer.Mapping()); err != nil {
+			return fmt.Errorf("writing --anonymize-map: %w", err)
+		}
+	}
+
+	if etags != nil {
+		if err := etags.Save(); err != nil {
+			return fmt.Errorf("saving ETag cache: %w", err)
+		}
+	}
+
+	// Streamed records are written as they are fetched, a sample once the
+	// export is complete.
+	if !streaming {
+		if dir := c.String("patch-dir"); dir != "" {
+			if err := exporter.WritePatches(&export, dir); err != nil {
 				return err
 			}
 		}
-
-	case "releases":
-		// Write releases
-		for _, release := range export.Releases {
-			row := []string{"Release", release.Repo, release.TagName, release.Name, "", release.Author, release.Date.String()}
-			if err := writer.Write(row); err != nil {
+		// An interrupted export is written out without its assets.
+		if dir := c.String("download-assets"); dir != "" && !interrupted {
+			if err := exporter.DownloadAssets(ctx, client, storage, &export, dir); err != nil {
 				return err
 			}
 		}
-	case "watch":
-		// Write watch
-		for _, watch := range export.Watch {
-			row := []string{"Watch", watch.Repo, "", "", "", watch.Action, watch.Date.String()}
-			if err := writer.Write(row); err != nil {
+		// Every format is rendered from the same fetched export.
+		for i, format := range formats {
+			writeOpts.Format = format
+			if err := writeOutput(export, outputFiles[i], writeOpts, compress); err != nil {
 				return err
 			}
 		}
 	}
 
+	if interrupted {
+		return errInterrupted
+	}
+
+	if c.Bool("error-if-empty") && streamed+exporter.Count(export, kind) == 0 {
+		if fetched := stats.Records(); fetched > 0 {
+			return fmt.Errorf("the export is empty: Github returned %d records but the filters excluded all of them", fetched)
+		}
+		return fmt.Errorf("the export is empty: Github returned no records, there was no activity or the token cannot see it")
+	}
+
+	resume.Observe(export, kind)
+	if path := c.String("resume-token-file"); path != "" {
+		if err := os.WriteFile(path, []byte(resume.String()+"\n"), 0644); err != nil {
+			return fmt.Errorf("writing --resume-token-file: %w", err)
+		}
+	}
+	logger.Info("resume token", "token", resume.String())
+
+	destinations := make([]string, len(outputFiles))
+	for i, path := range outputFiles {
+		destinations[i] = path
+		if path == "-" {
+			destinations[i] = "stdout"
+		}
+	}
+	logger.Info("export completed", "outputs", destinations)
 	return nil
 }
 
-func outputStdOut(export Export, kind string) error {
-	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.Debug)
-	defer writer.Flush()
+// envTokens returns the tokens from the environment. Like the gh CLI,
+// GITHUB_ENTERPRISE_TOKEN takes precedence for Enterprise hosts so a
+// github.com token in GITHUB_TOKEN is not sent to them.
+func envTokens(host string) []string {
+	value := os.Getenv("GITHUB_TOKEN")
+	if isEnterprise(host) {
+		if token := os.Getenv("GITHUB_ENTERPRISE_TOKEN"); token != "" {
+			value = token
+		}
+	}
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// isEnterprise reports whether host is a Github Enterprise Server host.
+func isEnterprise(host string) bool {
+	return host != "" && host != defaultHost
+}
+
+// newClient builds the Github client. The transport is constructed
+// explicitly so proxy and TLS settings apply underneath the authentication
+// and retry layers. Requests authenticate as a Github App installation when
+// --app-id is set and with the tokens otherwise; the returned token transport
+// is nil for App authentication. An Enterprise host is reached through its
+// /api/v3 endpoint. The returned ETag cache is nil unless
+// --etag-cache is set and must be saved once the export is done.
+func newClient(c *cli.Context, host string, tokens []string, rates *exporter.RateTracker) (*github.Client, *exporter.TokenTransport, *exporter.ETagCache, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if c.Bool("insecure-skip-verify") {
+		logger.Warn("TLS certificate verification is disabled, connections to Github are not secure")
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
 
-	switch kind {
+	rates.Base = transport
+	var base http.RoundTripper = rates
+	if c.Bool("debug") {
+		base = &exporter.DebugTransport{Base: base, Logger: logAt(slog.LevelDebug)}
+	}
 
-	case "commits":
-		// Write commits
-		fmt.Fprintln(writer, "Date\tRepo\tSHA\tAuthor\tMessage")
-		for _, commit := range export.Commits {
-			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", commit.Date, commit.Repo, commit.SHA, commit.Author, commit.Message)
+	if dir := c.String("cache-dir"); dir != "" && !c.Bool("no-cache") {
+		if c.String("etag-cache") != "" {
+			return nil, nil, nil, fmt.Errorf("--cache-dir and --etag-cache cannot be combined")
 		}
+		base = &exporter.DiskCache{Base: base, Dir: dir}
+	}
 
-	case "pull_requests":
-		// Write pull requests
-		fmt.Fprintln(writer, "Date\tRepo\tNumber\tTitle\tState\tAuthor")
-		for _, pr := range export.PullRequests {
-			fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\n", pr.Date, pr.Repo, pr.Number, pr.Title, pr.State, pr.Author)
+	var etags *exporter.ETagCache
+	if path := c.String("etag-cache"); path != "" && !c.Bool("no-cache") {
+		var err error
+		etags, err = exporter.NewETagCache(path, base)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("loading ETag cache: %w", err)
 		}
-	case "issues":
-		// Write issues
-		fmt.Fprintln(writer, "Date\tRepo\tNumber\tTitle\tState\tAuthor")
-		for _, issue := range export.Issues {
-			fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\n",
-				issue.Date, issue.Repo, issue.Number, issue.Title, issue.State, issue.Author)
+		base = etags
+	}
+
+	// The authentication layer sits below the retry layer, so with several
+	// tokens a request is only retried after waiting once every token is
+	// rate limited.
+	var auth http.RoundTripper
+	var tokenTransport *exporter.TokenTransport
+	if c.IsSet("app-id") {
+		installation, err := ghinstallation.NewKeyFromFile(base, c.Int64("app-id"), c.Int64("installation-id"), c.String("private-key"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("loading Github App private key: %w", err)
 		}
-	case "releases":
-		// Write releases
-		fmt.Fprintln(writer, "Date\tRepo\tTag\tName\tAuthor")
-		for _, release := range export.Releases {
-			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", release.Date, release.Repo, release.TagName, release.Name, release.Author)
+		if isEnterprise(host) {
+			installation.BaseURL = "https://" + host + "/api/v3"
 		}
-	case "watch":
-		// Write watch
-		fmt.Fprintln(writer, "Date\tRepo\tAction")
-		for _, watch := range export.Watch {
-			fmt.Fprintf(writer, "%s\t%s\t%s\n", watch.Date, watch.Repo, watch.Action)
+		auth = installation
+	} else {
+		tokenTransport = exporter.NewTokenTransport(tokens, base)
+		auth = tokenTransport
+	}
+
+	retry := &exporter.RetryTransport{Base: auth, MaxRetries: c.Int("max-retries"), MaxWait: c.Duration("max-wait"), Timeout: c.Duration("timeout")}
+	if c.Bool("verbose") {
+		retry.Logger = logAt(slog.LevelInfo)
+	}
+
+	client := github.NewClient(&http.Client{Transport: retry})
+	if isEnterprise(host) {
+		var err error
+		client, err = client.WithEnterpriseURLs("https://"+host+"/api/v3/", "https://"+host+"/api/uploads/")
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid --hostname: %w", err)
 		}
+	}
+	return client, tokenTransport, etags, nil
+}
 
+// storageClient returns the client following the redirects of asset downloads
+// to storage, which rejects the API credentials. It shares the transport of
+// the API client built by newClient with rates, minus the authentication and
+// caches.
+func storageClient(c *cli.Context, rates *exporter.RateTracker) *http.Client {
+	var base http.RoundTripper = rates
+	if c.Bool("debug") {
+		base = &exporter.DebugTransport{Base: base, Logger: logAt(slog.LevelDebug)}
 	}
-	return nil
+	retry := &exporter.RetryTransport{Base: base, MaxRetries: c.Int("max-retries"), MaxWait: c.Duration("max-wait")}
+	if c.Bool("verbose") {
+		retry.Logger = logAt(slog.LevelInfo)
+	}
+	return &http.Client{Transport: retry}
 }
 
-func fetchGitHubEvents(ctx context.Context, client *github.Client) (Export, error) {
-	export := Export{}
+// resolveTokens returns the API tokens given with --token, or else those of
+// the environment or of the gh CLI configuration, along with the host they
+// are for.
+func resolveTokens(c *cli.Context, host string) ([]string, string, error) {
+	tokens := c.StringSlice("token")
+	if len(tokens) == 0 {
+		tokens = envTokens(host)
+	}
+	if len(tokens) == 0 && c.Bool("from-gh-config") {
+		token, configHost, err := tokenFromGHConfig(host)
+		if err != nil {
+			return nil, "", err
+		}
+		tokens, host = []string{token}, configHost
+	}
+	if len(tokens) == 0 {
+		return nil, "", fmt.Errorf("a Github API access token is required (--token, GITHUB_TOKEN or --from-gh-config)")
+	}
+	return tokens, host, nil
+}
 
-	user, _, err := client.Users.Get(ctx, "")
+// listRepos prints the repositories an export would walk under the
+// repository flags, without fetching any of their activity.
+func listRepos(c *cli.Context) error {
+	host := c.String("hostname")
+	var tokens []string
+	if c.IsSet("app-id") {
+		if !c.IsSet("installation-id") || c.String("private-key") == "" {
+			return fmt.Errorf("--app-id requires --installation-id and --private-key")
+		}
+	} else {
+		var err error
+		if tokens, host, err = resolveTokens(c, host); err != nil {
+			return err
+		}
+	}
+
+	pushedSince, err := parseDate(c.String("pushed-since"))
 	if err != nil {
-		return export, err
+		return fmt.Errorf("invalid --pushed-since: %w", err)
 	}
 
-	opt := &github.ListOptions{PerPage: 100}
-	for {
-		events, resp, err := client.Activity.ListEventsPerformedByUser(ctx, *user.Login, false, opt)
-		if err != nil {
-			return export, err
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client, _, etags, err := newClient(c, host, tokens, &exporter.RateTracker{})
+	if err != nil {
+		return err
+	}
+
+	opts := exporter.Options{
+		Repos:             c.StringSlice("repo"),
+		InstallationRepos: c.IsSet("app-id"),
+		MinStars:          c.Int("min-stars"),
+		PushedSince:       pushedSince,
+		ExcludeForks:      c.Bool("exclude-forks"),
+		ExcludeArchived:   c.Bool("exclude-archived"),
+		RepoCache:         c.String("repo-cache"),
+		RepoCacheTTL:      c.Duration("repo-cache-ttl"),
+		RefreshRepoCache:  c.Bool("refresh"),
+		Warnings:          logAt(slog.LevelWarn),
+	}
+	repos, err := exporter.Repositories(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+	if etags != nil {
+		if err := etags.Save(); err != nil {
+			return fmt.Errorf("saving ETag cache: %w", err)
 		}
+	}
+	return exporter.WriteRepositories(repos, os.Stdout, c.String("format"))
+}
 
-		for _, event := range events {
-			if event.GetActor().GetLogin() != *user.Login {
-				continue
+func printSchema(c *cli.Context) error {
+	schema, err := exporter.Schema()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(schema))
+	return nil
+}
+
+// identityFlags are the flags whose values name users, teams, organizations
+// or repositories, left out of the metadata of an anonymized export.
+var identityFlags = []string{"author", "members", "team", "repo", "query", "anonymize-map"}
+
+// setFlags returns the flags set on the command line or through the
+// environment, leaving out credentials and secrets, and identities when
+// anonymizing.
+func setFlags(c *cli.Context) map[string]string {
+	flags := map[string]string{}
+	for _, flag := range c.App.Flags {
+		name := flag.Names()[0]
+		if name == "token" || name == "anonymize-salt" || !c.IsSet(name) {
+			continue
+		}
+		if c.Bool("anonymize") && slices.Contains(identityFlags, name) {
+			continue
+		}
+		if _, ok := flag.(*cli.StringSliceFlag); ok {
+			flags[name] = strings.Join(c.StringSlice(name), ",")
+			continue
+		}
+		flags[name] = fmt.Sprint(c.Value(name))
+	}
+	return flags
+}
+
+// output is a destination of the export and the format written to it.
+type output struct {
+	path   string
+	format string
+	// named is set when the --output value named its format, as in
+	// report.json:json, and is then used as given.
+	named bool
+}
+
+// parseOutputs pairs the --output values with their formats. A value ending
+// in :FORMAT is written in that format; the others are written in each of
+// formats, as parsed from --format. A suffix that looks like a format name
+// but is none is rejected rather than taken as part of the path.
+func parseOutputs(values, formats []string) ([]output, error) {
+	var outputs []output
+	for _, value := range values {
+		if i := strings.LastIndex(value, ":"); i >= 0 && !strings.ContainsAny(value[i+1:], `./\`) {
+			if !exporter.ValidFormat(value[i+1:]) {
+				return nil, fmt.Errorf("unsupported format in --output %s: %s", value, value[i+1:])
 			}
+			outputs = append(outputs, output{path: value[:i], format: value[i+1:], named: true})
+			continue
+		}
+		for _, format := range formats {
+			outputs = append(outputs, output{path: value, format: format})
+		}
+	}
+	return outputs, nil
+}
 
-			payload, err := event.ParsePayload()
-			if err != nil {
-				continue
+// parseFormats splits a comma separated --format value. "all" stands for
+// json, csv and table.
+func parseFormats(value string) []string {
+	var formats []string
+	for _, format := range strings.Split(value, ",") {
+		format = strings.TrimSpace(format)
+		expanded := []string{format}
+		if format == "all" {
+			expanded = []string{"json", "csv", "table"}
+		}
+		for _, format := range expanded {
+			if !slices.Contains(formats, format) {
+				formats = append(formats, format)
 			}
+		}
+	}
+	return formats
+}
 
-			switch event.GetType() {
-			case "PushEvent":
-				if p, ok := payload.(*github.PushEvent); ok {
-					for _, commit := range p.Commits {
-						export.Commits = append(export.Commits, Commit{
-							Repo:    event.GetRepo().GetName(),
-							SHA:     commit.GetSHA(),
-							Message: *commit.Message,
-							Date:    event.GetCreatedAt().Time,
-						})
-					}
-				}
-			case "PullRequestEvent":
-				if p, ok := payload.(*github.PullRequestEvent); ok {
-					export.PullRequests = append(export.PullRequests, PullRequest{
-						Repo:   event.GetRepo().GetName(),
-						Number: p.GetPullRequest().GetNumber(),
-						Title:  p.GetPullRequest().GetTitle(),
-						Action: p.GetAction(),
-						Date:   event.GetCreatedAt().Time,
-					})
-				}
-			case "IssuesEvent":
-				if p, ok := payload.(*github.IssuesEvent); ok {
-					export.Issues = append(export.Issues, Issue{
-						Repo:   event.GetRepo().GetName(),
-						Number: p.GetIssue().GetNumber(),
-						Title:  p.GetIssue().GetTitle(),
-						Action: p.GetAction(),
-						Date:   event.GetCreatedAt().Time,
-					})
-				}
-			case "ReleaseEvent":
-				if p, ok := payload.(*github.ReleaseEvent); ok {
-					export.Releases = append(export.Releases, Release{
-						Repo:    event.GetRepo().GetName(),
-						TagName: p.GetRelease().GetTagName(),
-						Name:    p.GetRelease().GetName(),
-						Action:  p.GetAction(),
-						Date:    event.GetCreatedAt().Time,
-					})
-				}
-			case "WatchEvent":
-				if p, ok := payload.(*github.WatchEvent); ok {
-					export.Watch = append(export.Watch, Watch{
-						Repo:   event.GetRepo().GetName(),
-						Action: p.GetAction(),
-						Date:   event.GetCreatedAt().Time,
-					})
-				}
+// writesToFile reports whether format is written to a file by default rather
+// than to stdout.
+func writesToFile(format string) bool {
+	switch format {
+	case "json", "ndjson", "csv", "parquet", "prom", "template":
+		return true
+	}
+	return false
+}
+
+// writeOutput writes export to path, or to stdout when path is "-",
+// optionally gzip compressed.
+func writeOutput(export exporter.Export, path string, opts exporter.WriteOptions, compress bool) (err error) {
+	w, closeOutput, err := openOutput(path, compress, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := closeOutput(); err == nil {
+			err = cerr
+		}
+	}()
+	return exporter.Write(export, w, opts)
+}
+
+// openOutput opens path for writing, or stdout when path is "-", optionally
+// gzip compressed. With appending set the file is added to rather than
+// replaced; compressed output then becomes another gzip member, which gzip
+// readers decompress as one stream. The returned function must be called
+// once writing is done; it writes the gzip trailer and closes the file.
+func openOutput(path string, compress, appending bool) (io.Writer, func() error, error) {
+	var w io.Writer = os.Stdout
+	closeFile := func() error { return nil }
+	if path != "-" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, nil, err
+		}
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if appending {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		file, err := os.OpenFile(path, flags, 0666)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, closeFile = file, file.Close
+	}
+
+	if !compress {
+		return w, closeFile, nil
+	}
+
+	gz := gzip.NewWriter(w)
+	return gz, func() error {
+		// Close rather than Flush so the gzip trailer is written.
+		err := gz.Close()
+		if cerr := closeFile(); err == nil {
+			err = cerr
+		}
+		return err
+	}, nil
+}
+
+// loadKeys reads the identities of the records already in the export at path.
+// A missing file holds no records.
+func loadKeys(path string, opts exporter.WriteOptions, compress bool) (exporter.KeySet, error) {
+	keys := exporter.KeySet{}
+	err := readOutput(path, compress && opts.Append, func(r io.Reader) error {
+		var err error
+		keys, err = exporter.LoadKeys(r, opts)
+		return err
+	})
+	return keys, err
+}
+
+// readOutput calls read with the contents of the existing output file at
+// path, decompressing them with compress. A missing file is skipped.
+func readOutput(path string, compress bool, read func(io.Reader) error) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if compress {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return read(r)
+}
+
+// writeJSONFile writes v to path as indented JSON.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// parseDelimiter parses a --delimiter value: a single character, or tab.
+func parseDelimiter(value string) (rune, error) {
+	if value == "tab" {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	if size == 0 || size != len(value) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid --delimiter %q, expected a single character other than a quote or newline, or tab", value)
+	}
+	return r, nil
+}
+
+// parseFieldMap parses name=new pairs into a map from the current to the new
+// name. No two names may be renamed to the same new name.
+func parseFieldMap(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	fields := map[string]string{}
+	targets := map[string]bool{}
+	for _, pair := range pairs {
+		from, to, ok := strings.Cut(pair, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("%q is not a name=new pair", pair)
+		}
+		if targets[to] {
+			return nil, fmt.Errorf("more than one field is renamed to %s", to)
+		}
+		fields[from] = to
+		targets[to] = true
+	}
+	return fields, nil
+}
+
+// parseDate parses a YYYY-MM-DD date. An empty string yields the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// outputPath returns the file the export is written to, or "-" for stdout.
+//
+// With --output-dir the file is placed in that directory, named after an
+// explicit --output or generated otherwise. Without it the legacy behavior
+// applies: the directory part of --output is kept and the file name replaced
+// by a generated one, unless --no-timestamp asks for --output verbatim. An
+// --output with placeholders or naming its format is always used as given,
+// once the placeholders are expanded with vars.
+func outputPath(c *cli.Context, out output, kind string, compress bool, vars map[string]string) (string, error) {
+	output, format := out.path, out.format
+	if output == "-" || (!writesToFile(format) && !out.named) {
+		return "-", nil
+	}
+
+	if out.named || strings.Contains(output, "{") {
+		expanded, err := expandOutput(output, format, vars)
+		if err != nil {
+			return "", err
+		}
+		if dir := c.String("output-dir"); dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return "", err
 			}
+			return filepath.Join(dir, expanded), nil
+		}
+		return expanded, nil
+	}
 
+	name := generateFileName(kind, format, !c.Bool("no-timestamp"))
+	if compress {
+		name += ".gz"
+	}
+
+	if dir := c.String("output-dir"); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		if c.IsSet("output") {
+			return filepath.Join(dir, output), nil
 		}
+		return filepath.Join(dir, name), nil
+	}
 
-		if resp.NextPage == 0 {
-			break
+	if c.Bool("no-timestamp") && c.IsSet("output") {
+		// An explicit --output is used verbatim so scheduled runs can
+		// overwrite a stable path.
+		return output, nil
+	}
+	if c.IsSet("output") && !strings.HasSuffix(output, "/") {
+		logger.Warn("the file name in --output is replaced by a generated one, use --output-dir to choose the directory")
+	}
+	return filepath.Join(filepath.Dir(output), name), nil
+}
+
+var outputPlaceholder = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// outputOrg returns the organization of --team, or the owner of the
+// repositories in --repo when they all share one, and "" otherwise.
+func outputOrg(c *cli.Context) string {
+	if org, _, ok := strings.Cut(c.String("team"), "/"); ok {
+		return org
+	}
+	org := ""
+	for _, repo := range c.StringSlice("repo") {
+		owner, _, _ := strings.Cut(repo, "/")
+		if org != "" && !strings.EqualFold(owner, org) {
+			return ""
 		}
-		opt.Page = resp.NextPage
+		org = owner
 	}
+	return org
+}
 
-	return export, nil
+// expandOutput replaces the {user}, {kind}, {format}, {date} and {org}
+// placeholders of an --output value with vars and format. A placeholder
+// without a value is an error rather than an empty path segment.
+func expandOutput(output, format string, vars map[string]string) (string, error) {
+	var err error
+	expanded := outputPlaceholder.ReplaceAllStringFunc(output, func(match string) string {
+		name := match[1 : len(match)-1]
+		value, ok := vars[name]
+		if name == "format" {
+			value, ok = fileExtension(format), true
+		}
+		switch {
+		case err != nil:
+		case !ok:
+			err = fmt.Errorf("unknown placeholder %s in --output, expected {user}, {kind}, {format}, {date} or {org}", match)
+		case value == "":
+			err = fmt.Errorf("placeholder %s in --output has no value for this export", match)
+		}
+		return value
+	})
+	return expanded, err
 }
 
-func generateFilePath(filepath, kind, format string) string {
-	var filename string
-	timeNow := time.Now().Format("20060102")
-	switch format {
-	case "json":
-		filename = fmt.Sprintf("%s-%s-export-%s.%s", "github", kind, timeNow, "json")
-	case "csv":
-		filename = fmt.Sprintf("%s-%s-export-%s.%s", "github", kind, timeNow, "csv")
-	default:
-		filename = "stdout"
-	}
-	outputFile := filepath[:strings.LastIndex(filepath, "/")+1] + filename
-	return outputFile
+// generateFileName names the output file github-<kind>-export-<date>.<format>.
+// The date is left out when timestamp is false. The kind is always part of
+// the name so scripts can glob github-*-export-*.json however the export was
+// run; the README documents this scheme and it must not change lightly.
+func generateFileName(kind, format string, timestamp bool) string {
+	filename := fmt.Sprintf("%s-%s-export", "github", kind)
+	if timestamp {
+		filename += "-" + time.Now().Format("20060102")
+	}
+	return filename + "." + fileExtension(format)
+}
+
+// fileExtension returns the file name extension of format.
+func fileExtension(format string) string {
+	// Templates render to text of any shape.
+	if format == "template" {
+		return "txt"
+	}
+	return format
 }
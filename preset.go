@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// presets are named bundles of flag values selected with --preset.
+var presets = map[string][][2]string{
+	// Compact, compressed newline delimited JSON for long-term storage.
+	"archive": {{"format", "ndjson"}, {"compress", "true"}},
+	// Markdown release notes of commits or pull requests.
+	"report": {{"format", "changelog"}},
+	// A csv file to open in a spreadsheet application.
+	"spreadsheet": {{"format", "csv"}},
+}
+
+// presetNames returns the names of the presets, sorted.
+func presetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// applyPreset sets the flags of the preset named name, leaving the flags given
+// on the command line as they are.
+func applyPreset(c *cli.Context, name string) error {
+	preset, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset %q, expected one of %s", name, strings.Join(presetNames(), ", "))
+	}
+	for _, flag := range preset {
+		if c.IsSet(flag[0]) {
+			continue
+		}
+		if err := c.Set(flag[0], flag[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}